@@ -0,0 +1,188 @@
+// characters that can make text say something other than what it seems to
+//
+// bidi controls can reorder source code on screen ("Trojan Source"), invisible characters and odd spaces
+// hide in identifiers and strings, and confusables are letters from other scripts that look like ascii
+package audit
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/jcocozza/jte/internal/grapheme"
+)
+
+type Kind int
+
+const (
+	None Kind = iota
+	// reorders the text around it
+	Bidi
+	// takes up no room
+	Invisible
+	// a space that is not U+0020
+	Space
+	// looks like an ascii character
+	Confusable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Bidi:
+		return "bidi"
+	case Invisible:
+		return "invisible"
+	case Space:
+		return "space"
+	case Confusable:
+		return "confusable"
+	}
+	return "none"
+}
+
+// hidden characters cannot be seen, they are drawn as a placeholder
+func (k Kind) Hidden() bool {
+	return k == Bidi || k == Invisible
+}
+
+const zwj = 0x200D
+
+// the names of the characters that are not confusables
+var names = map[rune]string{
+	0x061C: "arabic letter mark",
+	0x200E: "left-to-right mark",
+	0x200F: "right-to-left mark",
+	0x202A: "left-to-right embedding",
+	0x202B: "right-to-left embedding",
+	0x202C: "pop directional formatting",
+	0x202D: "left-to-right override",
+	0x202E: "right-to-left override",
+	0x2066: "left-to-right isolate",
+	0x2067: "right-to-left isolate",
+	0x2068: "first strong isolate",
+	0x2069: "pop directional isolate",
+
+	0x115F: "hangul choseong filler",
+	0x1160: "hangul jungseong filler",
+	0x180E: "mongolian vowel separator",
+	0x200B: "zero width space",
+	0x200C: "zero width non-joiner",
+	zwj:    "zero width joiner",
+	0x2060: "word joiner",
+	0x2061: "function application",
+	0x2062: "invisible times",
+	0x2063: "invisible separator",
+	0x2064: "invisible plus",
+	0x3164: "hangul filler",
+	0xFEFF: "zero width no-break space",
+	0xFFA0: "halfwidth hangul filler",
+
+	0x00A0: "no-break space",
+	0x1680: "ogham space mark",
+	0x2000: "en quad",
+	0x2001: "em quad",
+	0x2002: "en space",
+	0x2003: "em space",
+	0x2004: "three-per-em space",
+	0x2005: "four-per-em space",
+	0x2006: "six-per-em space",
+	0x2007: "figure space",
+	0x2008: "punctuation space",
+	0x2009: "thin space",
+	0x200A: "hair space",
+	0x202F: "narrow no-break space",
+	0x205F: "medium mathematical space",
+	0x3000: "ideographic space",
+}
+
+// the ascii characters that confusables look like
+//
+// these are the common ones, from the cyrillic, greek and armenian letters and punctuation.
+// the fullwidth forms (U+FF01 to U+FF5E) are worked out from their ascii character
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ј': 'j',
+	'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'һ': 'h', 'ӏ': 'l',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T',
+	'Х': 'X', 'Ѕ': 'S', 'І': 'I', 'Ј': 'J', 'Ү': 'Y',
+	// greek
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O',
+	'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X', 'ο': 'o', 'ν': 'v',
+	// armenian
+	'օ': 'o', 'ս': 'u',
+	// latin
+	'ı': 'i', 'ɑ': 'a', 'ɡ': 'g', 'ǀ': 'l',
+	// punctuation
+	0x037E: ';', 0x2010: '-', 0x2011: '-', 0x2212: '-', 0x2024: '.', 0x2044: '/', 0x2215: '/', 0x02BC: '\'',
+}
+
+// the ascii character that r looks like, false if it is not a confusable
+func LooksLike(r rune) (rune, bool) {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		return r - 0xFEE0, true
+	}
+	c, ok := confusables[r]
+	return c, ok
+}
+
+// the kind of r on its own
+func Classify(r rune) Kind {
+	switch {
+	case r == 0x061C || r == 0x200E || r == 0x200F || (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069):
+		return Bidi
+	case r == 0x00A0 || r == 0x1680 || (r >= 0x2000 && r <= 0x200A) || r == 0x202F || r == 0x205F || r == 0x3000:
+		return Space
+	case names[r] != "":
+		return Invisible
+	}
+	if _, ok := LooksLike(r); ok {
+		return Confusable
+	}
+	return None
+}
+
+// the kind of the rune at index i of rs
+//
+// a zero width joiner is only hidden when it is not joining emoji (e.g. the family emoji)
+func At(rs []rune, i int) Kind {
+	k := Classify(rs[i])
+	if rs[i] == zwj && i > 0 {
+		prev := rs[i-1]
+		if grapheme.RuneWidth(prev) == 2 || prev == 0xFE0F || (prev >= 0x1F3FB && prev <= 0x1F3FF) {
+			return None
+		}
+	}
+	return k
+}
+
+// what is shown for r in a tooltip, e.g. "U+202E right-to-left override (bidi)"
+func Describe(r rune) string {
+	k := Classify(r)
+	if c, ok := LooksLike(r); ok {
+		return fmt.Sprintf("U+%04X %s, looks like %c (%s)", r, script(r), c, k)
+	}
+	return fmt.Sprintf("U+%04X %s (%s)", r, names[r], k)
+}
+
+// the name of the script r is from, e.g. Cyrillic
+func script(r rune) string {
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+	return "punctuation"
+}
+
+// a hidden character, as a placeholder that can be seen
+func Placeholder(r rune) string {
+	return fmt.Sprintf("<U+%04X>", r)
+}
+
+// the columns Placeholder(r) takes up
+func PlaceholderWidth(r rune) int {
+	digits := 4
+	for v := r >> 16; v > 0; v >>= 4 {
+		digits++
+	}
+	return len("<U+>") + digits
+}
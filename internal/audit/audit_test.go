@@ -0,0 +1,58 @@
+package audit
+
+import "testing"
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		s    string
+		i    int
+		want Kind
+	}{
+		{"a", 0, None},
+		{"a\u202eb", 1, Bidi},
+		{"\u2066", 0, Bidi},
+		{"a\u200bb", 1, Invisible},
+		{"\ufeff", 0, Invisible},
+		{"a\u200db", 1, Invisible},
+		// joining emoji
+		{"\U0001F468\u200d\U0001F469", 1, None},
+		{"\u2764\ufe0f\u200d\U0001F525", 2, None},
+		{"a\u00a0b", 1, Space},
+		{"\u0430", 0, Confusable},
+		{"\uff41", 0, Confusable},
+		{"é", 0, None},
+		{"世", 0, None},
+	}
+	for _, tt := range tests {
+		if got := At([]rune(tt.s), tt.i); got != tt.want {
+			t.Errorf("At(%q, %d) = %v, want %v", tt.s, tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want string
+	}{
+		{0x202E, "U+202E right-to-left override (bidi)"},
+		{0x200B, "U+200B zero width space (invisible)"},
+		{0x00A0, "U+00A0 no-break space (space)"},
+		{0x0430, "U+0430 Cyrillic, looks like a (confusable)"},
+		{0x037E, "U+037E punctuation, looks like ; (confusable)"},
+		{0x2212, "U+2212 punctuation, looks like - (confusable)"},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.r); got != tt.want {
+			t.Errorf("Describe(%U) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestPlaceholderWidth(t *testing.T) {
+	for _, r := range []rune{0x200B, 0x202E, 0xFEFF, 0xE0001, 0x10FFFF} {
+		if got, want := PlaceholderWidth(r), len(Placeholder(r)); got != want {
+			t.Errorf("PlaceholderWidth(%U) = %d, want %d", r, got, want)
+		}
+	}
+}
@@ -1,13 +1,18 @@
 package buffer
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"slices"
 
 	"github.com/jcocozza/jte/internal/fileutil"
 	//"github.com/jcocozza/jte/internal/dev"
 )
 
+var ErrReadOnly = errors.New("buffer is read only")
+
 // cursor location in the buffer
 //
 //	X - column
@@ -18,6 +23,10 @@ type Cursor struct {
 }
 
 // Represents a single row in the buffer
+//
+// the runes of a row are never changed in place: an edit gives the row new ones, or appends
+// past its end. so a row taken from the buffer stays as it was, e.g. for highlighting it in the
+// background
 type BufRow []rune
 
 func (b *BufRow) Insert(at int, content []rune) error {
@@ -29,7 +38,7 @@ func (b *BufRow) Insert(at int, content []rune) error {
 		*b = append(*b, content...)
 		return nil
 	}
-	*b = append((*b)[:at], append(content, (*b)[at:]...)...)
+	*b = slices.Concat((*b)[:at], content, (*b)[at:])
 	return nil
 }
 
@@ -56,10 +65,13 @@ func (b *BufRow) DeleteRange(start, end int) ([]rune, error) {
 		return nil, fmt.Errorf("start cannot be greater than end: %d > %d", start, end)
 	}
 	content := append([]rune(nil), (*b)[start:end]...)
-	*b = append((*b)[:start], (*b)[end:]...)
+	*b = slices.Concat((*b)[:start], (*b)[end:])
 	return content, nil
 }
 
+// the number of columns a tab takes up
+const TAB_STOP = 8
+
 func (b *BufRow) append(runes []rune) {
 	*b = append(*b, runes...)
 }
@@ -79,8 +91,10 @@ type Buffer struct {
 	Name string
 
 	// the rows in the underlying file
-	Rows   []BufRow
-	cursor *Cursor
+	Rows []BufRow
+	// the current view, and all of them
+	cursor *View
+	views  []*View
 
 	// state stuff
 	Modified bool
@@ -90,23 +104,49 @@ type Buffer struct {
 	FilePath string
 	FileType fileutil.FileType
 
+	// the signs next to the rows, they move with the rows as they are edited
+	Signs Signs
+
 	// events
 	em *EventManager
+	// called after the rows change, see OnEdit
+	onEdit []func(y int, added int)
 }
 
 func NewBuffer(name string, filePath string, readOnly bool, rows []BufRow, l *slog.Logger) *Buffer {
-	return &Buffer{
+	b := &Buffer{
 		Name:     name,
 		FilePath: filePath,
 		Rows:     rows,
 		ReadOnly: readOnly,
-		cursor:   &Cursor{},
 		em:       NewEventManager(l),
 	}
+	// the buffer's own view, kept on its text like the views of the panes
+	b.cursor = b.NewView(nil)
+	b.OnEdit(b.Signs.Edit)
+	return b
+}
+
+func (b *Buffer) ID() int {
+	return b.id
+}
+
+// call f after every change to the rows
+//
+// row y changed when added is 0, added rows were inserted at y when it is positive,
+// and -added rows were removed from y when it is negative
+func (b *Buffer) OnEdit(f func(y int, added int)) {
+	b.onEdit = append(b.onEdit, f)
+}
+
+func (b *Buffer) edited(y int, added int) {
+	for _, f := range b.onEdit {
+		f(y, added)
+	}
 }
 
 func ReadFileIntoBuffer(path string, l *slog.Logger) (*Buffer, error) {
-	content, writeable, ftype, err := fileutil.ReadFile(path)
+	content, writeable, err := fileutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +155,43 @@ func ReadFileIntoBuffer(path string, l *slog.Logger) (*Buffer, error) {
 	for i, row := range content {
 		bufrows[i] = BufRow(row)
 	}
-	buf := NewBuffer(path, path, readOnly, bufrows, l)
-	buf.FileType = ftype
-	return buf, nil
+	return NewBuffer(path, path, readOnly, bufrows, l), nil
+}
+
+// a buffer with the contents of r, that is not backed by a file (e.g. stdin)
+func ReadIntoBuffer(name string, r io.Reader, l *slog.Logger) (*Buffer, error) {
+	content, err := fileutil.ReadLines(r)
+	if err != nil {
+		return nil, err
+	}
+	bufrows := make([]BufRow, len(content))
+	for i, row := range content {
+		bufrows[i] = BufRow(row)
+	}
+	return NewBuffer(name, "", false, bufrows, l), nil
+}
+
+// the contents of the buffer as they are written to a file, every line ends in a new line
+func (b *Buffer) Bytes() []byte {
+	var out []byte
+	for _, row := range b.Rows {
+		out = append(out, string(row)...)
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// write the buffer to its file
+//
+// returns the number of bytes written
+func (b *Buffer) Save() (int, error) {
+	if b.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	n, err := fileutil.Save(b.FilePath, b.Bytes())
+	if err != nil {
+		return n, err
+	}
+	b.Modified = false
+	return n, nil
 }
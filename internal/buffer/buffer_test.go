@@ -45,9 +45,9 @@ func TestBuffer_InsertAt(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			b := &Buffer{
 				Rows:   append([]BufRow{}, tt.initial...),
-				cursor: &Cursor{},
+				cursor: &View{},
 			}
-			err := b.InsertAt(tt.at, tt.content)
+			err := b.insertAt(tt.at, tt.content)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("InsertAt() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -120,9 +120,10 @@ func TestBuffer_DeleteAt(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := &Buffer{
-				Rows: append([]BufRow{}, tt.initial...),
+				Rows:   append([]BufRow{}, tt.initial...),
+				cursor: &View{},
 			}
-			got, err := b.DeleteAt(tt.start, tt.end)
+			got, err := b.deleteAt(tt.start, tt.end)
 			if (err != nil) != tt.expectError {
 				t.Fatalf("DeleteAt() error = %v, wantErr %v", err, tt.expectError)
 			}
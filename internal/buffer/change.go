@@ -1,17 +1,19 @@
 package buffer
 
+import "fmt"
+
 // a change is something applied to a buffer
 type Change interface {
 	Apply(buf *Buffer) error
 }
 
 type InsertAt struct {
-	cur      Cursor
-	contents [][]rune
+	Cur      Cursor
+	Contents [][]rune
 }
 
 func (i InsertAt) Apply(buf *Buffer) error {
-	return buf.insertAt(i.cur, i.contents)
+	return buf.insertAt(i.Cur, i.Contents)
 }
 
 // insert at the buffer's internal cursor
@@ -99,3 +101,20 @@ func (d DeleteLine) Apply(buf *Buffer) error {
 	d.contents = content
 	return nil
 }
+
+// replace the contents of the row at Y
+type SetRow struct {
+	Y        int
+	Contents []rune
+}
+
+func (s SetRow) Apply(buf *Buffer) error {
+	if s.Y < 0 || s.Y >= len(buf.Rows) {
+		return fmt.Errorf("cannot set row %d", s.Y)
+	}
+	buf.Rows[s.Y] = BufRow(s.Contents)
+	buf.edited(s.Y, 0)
+	buf.adjustCursor()
+	buf.moveViews(func(v *View) {})
+	return nil
+}
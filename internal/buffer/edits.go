@@ -19,16 +19,20 @@ func (b *Buffer) insertRowAt(at int, row []rune) error {
 		return fmt.Errorf("can not insert row at %d", at)
 	}
 	b.Rows = append(b.Rows[:at], append([]BufRow{row}, b.Rows[at:]...)...)
+	b.edited(at, 1)
+	b.moveViews(func(v *View) {
+		if v.Y >= at {
+			v.Y++
+		}
+	})
 	return nil
 }
 
+// split the row at the cursor, the rest of the row moves to a new row below
+//
+// the inserted row is placed at the start of the new row
 func (b *Buffer) insertRow(row []rune) error {
-	err := b.insertRowAt(b.cursor.Y, row)
-	if err != nil {
-		return err
-	}
-	b.cursor.Y++
-	return nil
+	return b.insert([][]rune{{}, row})
 }
 
 
@@ -38,33 +42,72 @@ func (b *Buffer) deleteRow(at int) ([]rune, error) {
 	}
 	content := b.Rows[at]
 	b.Rows = append(b.Rows[:at], b.Rows[at+1:]...)
+	b.edited(at, -1)
+	// a view on the row is left on the one after it
+	b.moveViews(func(v *View) {
+		if v.Y > at {
+			v.Y--
+		}
+	})
 	return content, nil
 }
 
 // insert at a specified cursor spot
+//
+// the rest of the row after the cursor is moved to the end of the inserted content
 func (b *Buffer) insertAt(at Cursor, content [][]rune) error {
 	if err := b.validCursor(at); err != nil {
 		//dev.Assert(err)
 		return err
 	}
-	if len(content) >= 1 {
-		err := b.Rows[at.Y].Insert(at.X, content[0])
+	if len(content) == 0 {
+		return nil
+	}
+	if len(content) == 1 {
+		err := b.Rows[at.Y].Insert(at.X, append([]rune(nil), content[0]...))
 		if err != nil {
 			return err
 		}
-		b.cursor.X++
-	}
-	for j := 1; j < len(content); j++ {
-		b.insertRowAt(at.Y+j, content[j])
-		b.cursor.Y++
-		b.cursor.X = len(content[j])
+		b.edited(at.Y, 0)
+		b.cursor.Y = at.Y
+		b.cursor.X = at.X + len(content[0])
+		// the other views stay on the text they were on
+		b.moveViews(func(v *View) {
+			if v.Y == at.Y && v.X >= at.X {
+				v.X += len(content[0])
+			}
+		})
+		return nil
+	}
+	row := b.Rows[at.Y]
+	tail := append([]rune(nil), row[at.X:]...)
+	last := len(content) - 1
+	// the rows below go in first, the views on the tail of the row move down with it
+	for j := 1; j <= last; j++ {
+		line := append([]rune(nil), content[j]...)
+		if j == last {
+			line = append(line, tail...)
+		}
+		if err := b.insertRowAt(at.Y+j, line); err != nil {
+			return err
+		}
 	}
+	b.Rows[at.Y] = append(row[:at.X:at.X], content[0]...)
+	b.edited(at.Y, 0)
+	b.moveViews(func(v *View) {
+		if v.Y == at.Y && v.X >= at.X {
+			v.Y += last
+			v.X += len(content[last]) - at.X
+		}
+	})
+	b.cursor.Y = at.Y + last
+	b.cursor.X = len(content[last])
 	return nil
 }
 
 // insert at the internal cursor
 func (b *Buffer) insert(content [][]rune) error {
-	return b.insertAt(*b.cursor, content)
+	return b.insertAt(b.cursor.Cursor, content)
 }
 
 // delete at a specified cursor
@@ -91,17 +134,27 @@ func (b *Buffer) deleteAt(start Cursor, end Cursor) ([][]rune, error) {
 		if err != nil {
 			return nil, err
 		}
+		b.edited(start.Y, 0)
+		b.moveViews(func(v *View) {
+			switch {
+			case v.Y != start.Y || v.X <= start.X:
+			case v.X < end.X:
+				v.X = start.X
+			default:
+				v.X -= end.X - start.X
+			}
+		})
 		return [][]rune{content}, nil
 	}
 
-	allDeleted := make([][]rune, end.Y-start.Y)
+	allDeleted := make([][]rune, end.Y-start.Y+1)
 	// harder case, more then one line
 	deletedHead, err := b.Rows[start.Y].DeleteRange(start.X, len(b.Rows[start.Y]))
 	if err != nil {
 		return nil, fmt.Errorf("unable to delete head from range: %w", err)
 	}
 	allDeleted[0] = deletedHead
-	deletedTail, err := b.Rows[end.Y].DeleteRange(0, end.X+1)
+	deletedTail, err := b.Rows[end.Y].DeleteRange(0, min(end.X+1, len(b.Rows[end.Y])))
 	if err != nil {
 		return nil, fmt.Errorf("unable to delete tail from range: %w", err)
 	}
@@ -114,14 +167,51 @@ func (b *Buffer) deleteAt(start Cursor, end Cursor) ([][]rune, error) {
 	}
 	// actually delete everything inbetween
 	b.Rows = append(b.Rows[:start.Y+1], b.Rows[end.Y+1:]...)
+	b.edited(start.Y, 0)
+	b.edited(start.Y+1, start.Y-end.Y)
+	b.moveViews(func(v *View) {
+		switch {
+		case v.Y < start.Y || (v.Y == start.Y && v.X <= start.X):
+		case v.Y < end.Y || (v.Y == end.Y && v.X <= end.X):
+			v.Cursor = start
+		case v.Y == end.Y:
+			v.Y, v.X = start.Y, start.X+v.X-(end.X+1)
+		default:
+			v.Y -= end.Y - start.Y
+		}
+	})
 
-	b.cursor = &start
+	b.cursor.Cursor = start
 	return allDeleted, nil
 }
 
-// wrapper around DeleteAt for the current cursor position
+// delete the character under the cursor
+//
+// at the end of a line, the next line is joined onto it
 func (b *Buffer) delete() ([][]rune, error) {
-	return b.deleteAt(*b.cursor, *b.cursor)
+	cur := b.cursor.Cursor
+	if cur.Y >= len(b.Rows) {
+		return nil, nil
+	}
+	if cur.X < len(b.Rows[cur.Y]) {
+		return b.deleteAt(cur, Cursor{X: cur.X + 1, Y: cur.Y})
+	}
+	if cur.Y+1 >= len(b.Rows) {
+		return nil, nil
+	}
+	// the next row is joined on first, so the views on it can move up with it
+	n := len(b.Rows[cur.Y])
+	b.Rows[cur.Y].append(b.Rows[cur.Y+1])
+	b.edited(cur.Y, 0)
+	b.moveViews(func(v *View) {
+		if v.Y == cur.Y+1 {
+			v.Y, v.X = cur.Y, v.X+n
+		}
+	})
+	if _, err := b.deleteRow(cur.Y + 1); err != nil {
+		return nil, err
+	}
+	return [][]rune{{}, {}}, nil
 }
 
 func (b *Buffer) backspace() ([][]rune, error) {
@@ -136,11 +226,23 @@ func (b *Buffer) backspace() ([][]rune, error) {
 		if err != nil {
 			return nil, err
 		}
+		b.edited(b.cursor.Y, 0)
 		b.cursor.X--
+		b.moveViews(func(v *View) {
+			if v.Y == b.cursor.Y && v.X > b.cursor.X {
+				v.X--
+			}
+		})
 		return [][]rune{{r}}, nil
 	} else {
 		newX := len(b.Rows[b.cursor.Y-1])
 		b.Rows[b.cursor.Y-1].append(b.Rows[b.cursor.Y])
+		b.edited(b.cursor.Y-1, 0)
+		b.moveViews(func(v *View) {
+			if v.Y == b.cursor.Y {
+				v.Y, v.X = v.Y-1, v.X+newX
+			}
+		})
 		rns, err := b.deleteRow(b.cursor.Y)
 		if err != nil { return nil, err }
 		b.cursor.Y--
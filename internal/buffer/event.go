@@ -55,6 +55,9 @@ func NewEventManager(l *slog.Logger) *EventManager {
 }
 
 func (e *EventManager) Commit() {
+	if e.current == nil {
+		return
+	}
 	e.current.complete = true
 	e.history.Push(*e.current)
 	e.current = nil
@@ -74,5 +77,5 @@ func (e *EventManager) StartEvent(etype EventType) error {
 
 func (e *EventManager) AddChange(c Change) {
 	e.current.changes = append(e.current.changes, c)
-	e.logger.Debug("added change: ", c)
+	e.logger.Debug("added change", slog.Any("change", c))
 }
@@ -0,0 +1,163 @@
+package buffer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/audit"
+	"github.com/jcocozza/jte/internal/grapheme"
+)
+
+// how a grapheme cluster of a row is shown on the screen
+//
+// the renderer and cursor movement both go through glyphs, so that they agree on columns
+type Glyph struct {
+	// the runes [Index, Index+Len) of the row
+	Index, Len int
+	// the display column the glyph starts at, and how many columns it takes up
+	Col, Width int
+	// what is drawn: the cluster itself, spaces for a tab, or a placeholder for a control character
+	Text string
+	// a control character, drawn as ^M or <0x9b>
+	Control bool
+	// a character that can hide what the text says (e.g. a bidi control), hidden ones are drawn as <U+202E>
+	Audit audit.Kind
+}
+
+// how a control character is drawn, ^X for the C0 controls and DEL, <0xXX> for the C1 controls
+func controlText(r rune) string {
+	switch {
+	case r < 0x20:
+		return "^" + string(r+'@')
+	case r == 0x7F:
+		return "^?"
+	}
+	return fmt.Sprintf("<0x%02x>", r)
+}
+
+// the columns controlText(r) takes up
+func controlWidth(r rune) int {
+	if r < 0x20 || r == 0x7F {
+		return len("^X")
+	}
+	return len("<0xXX>")
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7F || (r >= 0x80 && r < 0xA0)
+}
+
+// the glyphs of the row, in order
+func (b BufRow) Glyphs() []Glyph {
+	var glyphs []Glyph
+	for g := range b.layout {
+		g.Text = b.text(g)
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// walk the glyphs of the row in order, until yield returns false
+//
+// the glyphs are without their Text, so that finding columns and boundaries does not allocate
+func (b BufRow) layout(yield func(g Glyph) bool) {
+	col := 0
+	// the next hidden character, it is not part of the cluster before it so that it can be seen
+	hidden := -1
+	for i := 0; i < len(b); {
+		if hidden < i {
+			for hidden = i; hidden < len(b) && !audit.At(b, hidden).Hidden(); hidden++ {
+			}
+		}
+		g := Glyph{Index: i, Len: 1, Col: col, Audit: audit.At(b, i)}
+		switch r := b[i]; {
+		case r == '\t':
+			g.Width = TAB_STOP - (col % TAB_STOP)
+		case isControl(r):
+			g.Width = controlWidth(r)
+			g.Control = true
+		case g.Audit.Hidden():
+			g.Width = audit.PlaceholderWidth(r)
+		default:
+			g.Len, g.Width = grapheme.Next(b[i:hidden])
+		}
+		if !yield(g) {
+			return
+		}
+		i += g.Len
+		col += g.Width
+	}
+}
+
+// what is drawn for glyph g of the row
+func (b BufRow) text(g Glyph) string {
+	switch r := b[g.Index]; {
+	case r == '\t':
+		return strings.Repeat(" ", g.Width)
+	case g.Control:
+		return controlText(r)
+	case g.Audit.Hidden():
+		return audit.Placeholder(r)
+	}
+	return string(b[g.Index : g.Index+g.Len])
+}
+
+// the glyph with the rune at index x, without its Text, false past the end of the row
+func (b BufRow) glyphAt(x int) (Glyph, bool) {
+	for g := range b.layout {
+		if x < g.Index+g.Len {
+			return g, true
+		}
+	}
+	return Glyph{}, false
+}
+
+// the display column of the rune at index x
+//
+// a rune inside a grapheme cluster is at the column the cluster starts at
+func (b BufRow) DisplayCol(x int) int {
+	col := 0
+	for g := range b.layout {
+		if x < g.Index+g.Len {
+			return g.Col
+		}
+		col = g.Col + g.Width
+	}
+	return col
+}
+
+// the index of the rune that is shown at display column col
+//
+// a column past the end of the row is the end of the row
+func (b BufRow) IndexOfCol(col int) int {
+	for g := range b.layout {
+		if col < g.Col+g.Width {
+			return g.Index
+		}
+	}
+	return len(b)
+}
+
+// the start of the grapheme cluster with the rune at index x
+func (b BufRow) Boundary(x int) int {
+	if g, ok := b.glyphAt(x); ok {
+		return g.Index
+	}
+	return min(x, len(b))
+}
+
+// the start of the grapheme cluster after the one at index x
+func (b BufRow) NextBoundary(x int) int {
+	if g, ok := b.glyphAt(x); ok {
+		return g.Index + g.Len
+	}
+	return len(b)
+}
+
+// the start of the grapheme cluster before the one at index x
+func (b BufRow) PrevBoundary(x int) int {
+	if x <= 0 {
+		return 0
+	}
+	return b.Boundary(min(x, len(b)) - 1)
+}
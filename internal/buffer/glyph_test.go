@@ -0,0 +1,101 @@
+package buffer
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/audit"
+)
+
+func TestBufRow_Glyphs(t *testing.T) {
+	tests := []struct {
+		row  string
+		want []Glyph
+	}{
+		{"ab", []Glyph{{0, 1, 0, 1, "a", false, 0}, {1, 1, 1, 1, "b", false, 0}}},
+		{"世x", []Glyph{{0, 1, 0, 2, "世", false, 0}, {1, 1, 2, 1, "x", false, 0}}},
+		{"e\u0301x", []Glyph{{0, 2, 0, 1, "e\u0301", false, 0}, {2, 1, 1, 1, "x", false, 0}}},
+		{"a\tb", []Glyph{{0, 1, 0, 1, "a", false, 0}, {1, 1, 1, 7, "       ", false, 0}, {2, 1, 8, 1, "b", false, 0}}},
+		{"a\r", []Glyph{{0, 1, 0, 1, "a", false, 0}, {1, 1, 1, 2, "^M", true, 0}}},
+		{"\x1b\x7f", []Glyph{{0, 1, 0, 2, "^[", true, 0}, {1, 1, 2, 2, "^?", true, 0}}},
+		{"\u009b", []Glyph{{0, 1, 0, 6, "<0x9b>", true, 0}}},
+		// a hidden character is not part of the cluster before it
+		{"a\u202eb", []Glyph{{0, 1, 0, 1, "a", false, 0}, {1, 1, 1, 8, "<U+202E>", false, audit.Bidi}, {2, 1, 9, 1, "b", false, 0}}},
+		{"x\u200d", []Glyph{{0, 1, 0, 1, "x", false, 0}, {1, 1, 1, 8, "<U+200D>", false, audit.Invisible}}},
+		{"\U0001F468\u200d\U0001F469", []Glyph{{0, 3, 0, 2, "\U0001F468\u200d\U0001F469", false, 0}}},
+		{"\u0430\u00a0", []Glyph{{0, 1, 0, 1, "\u0430", false, audit.Confusable}, {1, 1, 1, 1, "\u00a0", false, audit.Space}}},
+	}
+	for _, tt := range tests {
+		got := BufRow(tt.row).Glyphs()
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.row, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: glyph %d is %+v, want %+v", tt.row, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestBufRow_Columns(t *testing.T) {
+	// a, then é in 2 runes over 1 column, 世 over 2 columns, ^M over 2 columns, then b
+	row := BufRow("ae\u0301世\rb")
+	cols := []int{0, 1, 1, 2, 4, 6}
+	for x, want := range cols {
+		if got := row.DisplayCol(x); got != want {
+			t.Errorf("DisplayCol(%d) = %d, want %d", x, got, want)
+		}
+	}
+	if got := row.DisplayCol(len(row)); got != 7 {
+		t.Errorf("DisplayCol(end) = %d, want 7", got)
+	}
+	index := []int{0, 1, 3, 3, 4, 4, 5, 6, 6}
+	for col, want := range index {
+		if got := row.IndexOfCol(col); got != want {
+			t.Errorf("IndexOfCol(%d) = %d, want %d", col, got, want)
+		}
+	}
+}
+
+func TestBufRow_ColumnsDoNotAllocate(t *testing.T) {
+	row := BufRow("a\tb 世界 e\u0301 \u202e\x1b end")
+	allocs := testing.AllocsPerRun(10, func() {
+		row.DisplayCol(len(row) - 1)
+		row.IndexOfCol(20)
+		row.NextBoundary(len(row) - 2)
+		row.PrevBoundary(len(row) - 1)
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations to find columns and boundaries, want none", allocs)
+	}
+}
+
+func TestBuffer_LeftRight(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	buf := NewBuffer("", "", false, []BufRow{BufRow("ae\u0301👍🏽\tb")}, l)
+	var xs []int
+	for buf.Right() {
+		xs = append(xs, buf.X())
+	}
+	for buf.Left() {
+		xs = append(xs, buf.X())
+	}
+	want := []int{1, 3, 5, 6, 7, 6, 5, 3, 1, 0}
+	if len(xs) != len(want) {
+		t.Fatalf("got %v, want %v", xs, want)
+	}
+	for i := range xs {
+		if xs[i] != want[i] {
+			t.Fatalf("got %v, want %v", xs, want)
+		}
+	}
+
+	// the cursor is moved off the middle of a cluster
+	buf.SetCursor(2, 0)
+	if buf.X() != 1 {
+		t.Errorf("SetCursor(2) put the cursor at %d, want 1", buf.X())
+	}
+}
@@ -12,6 +12,7 @@ func (b *Buffer) AcceptChange(c Change) error {
 	if err != nil {
 		return err
 	}
+	b.Modified = true
 	b.em.AddChange(c)
 	return nil
 }
@@ -27,6 +28,7 @@ func (b *Buffer) StartAndAcceptChange(c Change, etype EventType) error {
 			return err
 		}
 	}
+	b.Modified = true
 	b.em.AddChange(c)
 	return nil
 }
@@ -157,8 +157,9 @@ func (m *BufferManager) HasPath(path string) (bool, int, error) {
 }
 
 type BufListData struct {
-	Id      int
-	BufName string
+	Id       int
+	BufName  string
+	Modified bool
 }
 
 func (b *BufListData) String() string {
@@ -169,8 +170,9 @@ func (m *BufferManager) ListAll() []BufListData {
 	l := []BufListData{}
 	for id, buf := range m.bufMap {
 		b := BufListData{
-			Id:      id,
-			BufName: buf.Buf.Name,
+			Id:       id,
+			BufName:  buf.Buf.Name,
+			Modified: buf.Buf.Modified,
 		}
 		l = append(l, b)
 	}
@@ -19,27 +19,57 @@ func (b *Buffer) adjustCursor() {
 	if b.cursor.X > newRowLen {
 		b.cursor.X = newRowLen
 	}
+	// never in the middle of a grapheme cluster
+	b.cursor.X = b.Rows[b.cursor.Y].Boundary(b.cursor.X)
 }
 
-func (b *Buffer) Up() {
+// move the cursor to x, y
+//
+// the cursor is clamped to the buffer contents
+func (b *Buffer) SetCursor(x, y int) {
+	if y >= len(b.Rows) {
+		y = len(b.Rows) - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if x < 0 {
+		x = 0
+	}
+	b.cursor.Y = y
+	b.cursor.X = x
+	b.adjustCursor()
+}
+
+// the motions return false when the cursor could not be moved
+//
+// left and right move over a whole grapheme cluster (e.g. a letter and its accents)
+
+func (b *Buffer) Up() bool {
 	if b.cursor.Y > 0 {
-		b.cursor.Y--
-		b.adjustCursor()
+		b.moveToRow(b.cursor.Y - 1)
+		return true
 	}
+	return false
 }
-func (b *Buffer) Down() {
+func (b *Buffer) Down() bool {
 	if b.cursor.Y < len(b.Rows)-1 {
-		b.cursor.Y++
-		b.adjustCursor()
+		b.moveToRow(b.cursor.Y + 1)
+		return true
 	}
+	return false
 }
-func (b *Buffer) Left() {
-	if b.cursor.X > 0 {
-		b.cursor.X--
+func (b *Buffer) Left() bool {
+	if b.cursor.X > 0 && b.cursor.Y < len(b.Rows) {
+		b.cursor.X = b.Rows[b.cursor.Y].PrevBoundary(b.cursor.X)
+		return true
 	}
+	return false
 }
-func (b *Buffer) Right() {
+func (b *Buffer) Right() bool {
 	if b.cursor.Y < len(b.Rows) && b.cursor.X < len(b.Rows[b.cursor.Y]) {
-		b.cursor.X++
+		b.cursor.X = b.Rows[b.cursor.Y].NextBoundary(b.cursor.X)
+		return true
 	}
+	return false
 }
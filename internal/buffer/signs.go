@@ -0,0 +1,86 @@
+package buffer
+
+import (
+	"cmp"
+	"slices"
+)
+
+// a mark next to a line, e.g. a diagnostic, a breakpoint or a change
+//
+// how it is drawn is up to the editor, by its source
+type Sign struct {
+	// what placed the sign, e.g. "diagnostics", a source has at most one sign on a line
+	Source string
+	// what is shown, at most a couple of columns
+	Text string
+	// where a line has several signs the one with the highest priority is shown
+	Priority int
+}
+
+// the signs placed on the lines of a buffer
+//
+// the signs move with their lines as the buffer is edited (see Edit)
+type Signs struct {
+	lines map[int][]Sign
+}
+
+// place sign on line y, replacing the sign its source had there
+func (s *Signs) Place(y int, sign Sign) {
+	if s.lines == nil {
+		s.lines = map[int][]Sign{}
+	}
+	s.Remove(y, sign.Source)
+	s.lines[y] = append(s.lines[y], sign)
+}
+
+// remove the sign source placed on line y
+func (s *Signs) Remove(y int, source string) {
+	signs := slices.DeleteFunc(s.lines[y], func(sign Sign) bool { return sign.Source == source })
+	if len(signs) == 0 {
+		delete(s.lines, y)
+		return
+	}
+	s.lines[y] = signs
+}
+
+// remove every sign source placed
+func (s *Signs) Clear(source string) {
+	for y := range s.lines {
+		s.Remove(y, source)
+	}
+}
+
+// the sign shown on line y, the one with the highest priority
+func (s *Signs) At(y int) (Sign, bool) {
+	signs := s.lines[y]
+	if len(signs) == 0 {
+		return Sign{}, false
+	}
+	return slices.MaxFunc(signs, func(a, b Sign) int { return cmp.Compare(a.Priority, b.Priority) }), true
+}
+
+// the number of lines with signs
+func (s *Signs) Len() int {
+	return len(s.lines)
+}
+
+// move the signs for an edit of the buffer, see OnEdit
+//
+// the signs of removed lines go with them
+func (s *Signs) Edit(y int, added int) {
+	if added == 0 || len(s.lines) == 0 {
+		return
+	}
+	lines := make(map[int][]Sign, len(s.lines))
+	for ly, signs := range s.lines {
+		switch {
+		case ly < y:
+			lines[ly] = signs
+		case added < 0 && ly < y-added:
+			// removed
+		default:
+			lines[ly+added] = signs
+		}
+	}
+	s.lines = lines
+}
@@ -0,0 +1,36 @@
+package buffer
+
+import "testing"
+
+func TestSigns(t *testing.T) {
+	var s Signs
+	s.Place(3, Sign{Source: "marks", Text: "a", Priority: 1})
+	s.Place(3, Sign{Source: "diagnostics", Text: "E", Priority: 5})
+	s.Place(3, Sign{Source: "marks", Text: "b", Priority: 1})
+	if got, _ := s.At(3); got.Text != "E" {
+		t.Errorf("shown %q, want the sign with the highest priority", got.Text)
+	}
+	s.Remove(3, "diagnostics")
+	if got, _ := s.At(3); got.Text != "b" {
+		t.Errorf("shown %q, want the mark placed last", got.Text)
+	}
+
+	s.Place(5, Sign{Source: "marks", Text: "c"})
+	s.Place(8, Sign{Source: "marks", Text: "d"})
+	// lines inserted above move the signs down, removed lines take theirs
+	s.Edit(0, 2)
+	s.Edit(6, -2)
+	if _, ok := s.At(5); !ok {
+		t.Error("the sign of line 3 is not on line 5")
+	}
+	if _, ok := s.At(7); ok || s.Len() != 2 {
+		t.Errorf("the sign of a removed line was kept")
+	}
+	if got, _ := s.At(8); got.Text != "d" {
+		t.Errorf("line 8 shows %q, want the sign from line 8", got.Text)
+	}
+	s.Clear("marks")
+	if s.Len() != 0 {
+		t.Errorf("%d lines have signs after clearing", s.Len())
+	}
+}
@@ -0,0 +1,80 @@
+package buffer
+
+import "slices"
+
+// a cursor of its own into the buffer, e.g. for each pane that shows it
+//
+// moving and editing go through the buffer's current view (see SetView), the other views are
+// kept on the same text as the buffer is edited
+type View struct {
+	Cursor
+	// the display column up and down move to, so that moving through a shorter row does not
+	// lose it. it is taken from the cursor again once the cursor has moved away from wantAt
+	want   int
+	wantAt Cursor
+}
+
+// a view at the position of from, or of the current view when from is nil
+func (b *Buffer) NewView(from *View) *View {
+	if from == nil {
+		from = b.cursor
+	}
+	v := &View{}
+	if from != nil {
+		*v = *from
+	}
+	b.views = append(b.views, v)
+	return v
+}
+
+// move and edit through v from now on
+func (b *Buffer) SetView(v *View) {
+	b.cursor = v
+	b.clamp(v)
+}
+
+// forget v, e.g. when its pane is closed
+func (b *Buffer) CloseView(v *View) {
+	b.views = slices.DeleteFunc(b.views, func(o *View) bool { return o == v })
+}
+
+// keep v inside the rows, e.g. after rows were deleted
+func (b *Buffer) clamp(v *View) {
+	v.Y = max(min(v.Y, len(b.Rows)-1), 0)
+	if v.Y < len(b.Rows) {
+		v.X = b.Rows[v.Y].Boundary(max(min(v.X, len(b.Rows[v.Y])), 0))
+	} else {
+		v.X = 0
+	}
+}
+
+// move the views other than the current one for an edit, then keep them inside the rows
+func (b *Buffer) moveViews(f func(v *View)) {
+	for _, v := range b.views {
+		if v == b.cursor {
+			continue
+		}
+		f(v)
+		b.clamp(v)
+	}
+}
+
+// the display column to move up or down to
+func (b *Buffer) wantCol() int {
+	v := b.cursor
+	if v.wantAt != v.Cursor {
+		v.want = 0
+		if v.Y < len(b.Rows) {
+			v.want = b.Rows[v.Y].DisplayCol(v.X)
+		}
+	}
+	return v.want
+}
+
+// move the cursor to row y, at the column it wants to be at
+func (b *Buffer) moveToRow(y int) {
+	want := b.wantCol()
+	b.cursor.Y = y
+	b.cursor.X = b.Rows[y].IndexOfCol(want)
+	b.cursor.want, b.cursor.wantAt = want, b.cursor.Cursor
+}
@@ -0,0 +1,113 @@
+package buffer
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func newViewBuffer(rows ...string) *Buffer {
+	var bufrows []BufRow
+	for _, r := range rows {
+		bufrows = append(bufrows, BufRow(r))
+	}
+	return NewBuffer("b", "", false, bufrows, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestView_FollowsEdits(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   []string
+		other  Cursor
+		cursor Cursor
+		change Change
+		want   Cursor
+	}{
+		{"insert before on the row", []string{"abcdef"}, Cursor{X: 4}, Cursor{X: 1}, Insert{Contents: [][]rune{[]rune("xy")}}, Cursor{X: 6}},
+		{"insert after on the row", []string{"abcdef"}, Cursor{X: 1}, Cursor{X: 4}, Insert{Contents: [][]rune{[]rune("xy")}}, Cursor{X: 1}},
+		{"split the row", []string{"abcdef"}, Cursor{X: 4}, Cursor{X: 2}, EnterNewLine{}, Cursor{X: 2, Y: 1}},
+		{"insert rows above", []string{"a", "b"}, Cursor{X: 1, Y: 1}, Cursor{}, InsertAt{Cur: Cursor{}, Contents: [][]rune{[]rune("x"), []rune("y"), {}}}, Cursor{X: 1, Y: 3}},
+		{"delete on the row", []string{"abcdef"}, Cursor{X: 5}, Cursor{}, DeleteAt{StartCur: Cursor{X: 1}, EndCur: Cursor{X: 3}}, Cursor{X: 3}},
+		{"delete the text under it", []string{"abcdef"}, Cursor{X: 2}, Cursor{}, DeleteAt{StartCur: Cursor{X: 1}, EndCur: Cursor{X: 3}}, Cursor{X: 1}},
+		{"delete across rows", []string{"ab", "cd", "efgh", "i"}, Cursor{X: 3, Y: 2}, Cursor{}, DeleteAt{StartCur: Cursor{X: 1}, EndCur: Cursor{X: 1, Y: 2}}, Cursor{X: 2}},
+		{"rows below a deletion", []string{"ab", "cd", "ef"}, Cursor{X: 1, Y: 2}, Cursor{}, DeleteAt{StartCur: Cursor{X: 1}, EndCur: Cursor{X: 0, Y: 1}}, Cursor{X: 1, Y: 1}},
+		{"delete its row", []string{"a", "bcd", "e"}, Cursor{X: 2, Y: 1}, Cursor{}, DeleteLine{}, Cursor{X: 2, Y: 0}},
+		{"delete the row it is on", []string{"a", "bcd", "e"}, Cursor{X: 2, Y: 1}, Cursor{Y: 1}, DeleteLine{}, Cursor{X: 1, Y: 1}},
+		{"join", []string{"ab", "cd"}, Cursor{X: 1, Y: 1}, Cursor{X: 2}, Delete{}, Cursor{X: 3}},
+		{"backspace a row", []string{"ab", "cd"}, Cursor{X: 1, Y: 1}, Cursor{Y: 1}, Backspace{}, Cursor{X: 3}},
+		{"backspace on the row", []string{"abcd"}, Cursor{X: 3}, Cursor{X: 2}, Backspace{}, Cursor{X: 2}},
+	}
+	for _, tt := range tests {
+		b := newViewBuffer(tt.rows...)
+		other := b.NewView(nil)
+		other.Cursor = tt.other
+		b.SetView(b.NewView(nil))
+		b.SetCursor(tt.cursor.X, tt.cursor.Y)
+		if err := tt.change.Apply(b); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if other.Cursor != tt.want {
+			t.Errorf("%s: the other view is at %+v, want %+v", tt.name, other.Cursor, tt.want)
+		}
+	}
+}
+
+func TestView_WantedColumn(t *testing.T) {
+	b := newViewBuffer("abcdef", "ab", "a\tb", "abcdef")
+	b.SetCursor(5, 0)
+	b.Down()
+	if b.X() != 2 {
+		t.Fatalf("x = %d on a shorter row, want its end", b.X())
+	}
+	// the tab covers columns 1 to 7
+	b.Down()
+	if b.X() != 1 {
+		t.Errorf("x = %d on the row with a tab, want the tab", b.X())
+	}
+	b.Down()
+	if b.X() != 5 {
+		t.Errorf("x = %d, want the column from before the shorter rows", b.X())
+	}
+	// moving sideways sets the column again
+	b.Left()
+	b.Up()
+	b.Up()
+	b.Down()
+	b.Down()
+	if b.X() != 4 {
+		t.Errorf("x = %d, want 4", b.X())
+	}
+}
+
+func TestView_Independent(t *testing.T) {
+	b := newViewBuffer("abc", "def")
+	first := b.NewView(nil)
+	b.SetView(first)
+	b.SetCursor(2, 1)
+	second := b.NewView(first)
+	b.SetView(second)
+	b.Up()
+	if first.Cursor != (Cursor{X: 2, Y: 1}) || second.Cursor != (Cursor{X: 2, Y: 0}) {
+		t.Errorf("views at %+v and %+v", first.Cursor, second.Cursor)
+	}
+	b.CloseView(first)
+	b.SetView(second)
+	if slices.Contains(b.views, first) {
+		t.Error("the closed view is still moved with the buffer")
+	}
+}
+
+func TestView_BufferCursorMoves(t *testing.T) {
+	b := newViewBuffer("abc", "def")
+	b.SetCursor(1, 1)
+	own := b.cursor
+	b.SetView(b.NewView(nil))
+	b.SetCursor(0, 0)
+	if err := (InsertNewLine{Y: 0}).Apply(b); err != nil {
+		t.Fatal(err)
+	}
+	if own.Cursor != (Cursor{X: 1, Y: 2}) {
+		t.Errorf("the buffer's own view is at %+v, want it moved down with its row", own.Cursor)
+	}
+}
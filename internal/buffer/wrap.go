@@ -0,0 +1,98 @@
+package buffer
+
+import (
+	"slices"
+
+	"github.com/jcocozza/jte/internal/grapheme"
+)
+
+// how a row that is too long for its pane is split across screen lines
+type WrapOptions struct {
+	// break after a blank where there is one, rather than at the last character that fits
+	LineBreak bool
+	// start the lines after the first at the row's indent
+	BreakIndent bool
+	// shown at the start of the lines after the first
+	ShowBreak string
+}
+
+// a part of a row that is shown on a screen line of its own
+type Segment struct {
+	// the runes [Start, End) of the row
+	Start, End int
+	// the display column of the rune at Start, as the row is laid out unwrapped
+	Col int
+	// the blank columns before the text, for breakindent
+	Indent int
+	// the showbreak marker is drawn after the indent
+	Break bool
+	// the screen column the text starts at
+	X int
+}
+
+// the display columns a row starts with that are blank
+func (b BufRow) indentWidth() int {
+	for g := range b.layout {
+		if b[g.Index] != ' ' && b[g.Index] != '\t' {
+			return g.Col
+		}
+	}
+	return 0
+}
+
+// the screen lines the row takes up in width columns
+//
+// a glyph is never split, one that is wider than a whole line is clipped. the lines after the
+// first give up their indent when it would leave them less than half of width
+func (b BufRow) Wrap(width int, o WrapOptions) []Segment {
+	width = max(width, 1)
+	glyphs := slices.Collect(b.layout)
+	// how the lines after the first start
+	cont := Segment{Break: o.ShowBreak != ""}
+	if o.BreakIndent {
+		cont.Indent = b.indentWidth()
+	}
+	sbr := grapheme.Width(o.ShowBreak)
+	if cont.Indent+sbr > width/2 {
+		cont.Indent = 0
+	}
+	if sbr > width/2 {
+		cont.Break, sbr = false, 0
+	}
+	cont.X = cont.Indent + sbr
+
+	segs := []Segment{{}}
+	cur := &segs[0]
+	// the glyph after the last blank of the line, the line can be broken before it
+	afterBlank := -1
+	for i := 0; i < len(glyphs); i++ {
+		g := glyphs[i]
+		if g.Index > cur.Start && cur.X+g.Col+g.Width-cur.Col > width {
+			if o.LineBreak && afterBlank > 0 && glyphs[afterBlank].Index > cur.Start {
+				i = afterBlank
+				g = glyphs[i]
+			}
+			cur.End = g.Index
+			next := cont
+			next.Start, next.Col = g.Index, g.Col
+			segs = append(segs, next)
+			cur = &segs[len(segs)-1]
+			afterBlank = -1
+		}
+		if r := b[g.Index]; r == ' ' || r == '\t' {
+			afterBlank = i + 1
+		}
+	}
+	cur.End = len(b)
+	return segs
+}
+
+// the index of the segment with the rune at index x, the last one past the end of the row
+func SegmentAt(segs []Segment, x int) int {
+	for i, s := range segs {
+		if x < s.End {
+			return i
+		}
+	}
+	return len(segs) - 1
+}
@@ -0,0 +1,51 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBufRow_Wrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		row   string
+		width int
+		o     WrapOptions
+		// the text of each segment, and the column it starts at on the screen
+		want []string
+		x    []int
+	}{
+		{"short", "abc", 5, WrapOptions{}, []string{"abc"}, []int{0}},
+		{"empty", "", 5, WrapOptions{}, []string{""}, []int{0}},
+		{"exactly full", "abcde", 5, WrapOptions{}, []string{"abcde"}, []int{0}},
+		{"anywhere", "hello world", 4, WrapOptions{}, []string{"hell", "o wo", "rld"}, []int{0, 0, 0}},
+		{"at a blank", "hello big world", 10, WrapOptions{LineBreak: true}, []string{"hello big ", "world"}, []int{0, 0}},
+		{"a word longer than a line", "abcdefgh ij", 4, WrapOptions{LineBreak: true}, []string{"abcd", "efgh", " ij"}, []int{0, 0, 0}},
+		{"a wide character is not split", "ab世", 3, WrapOptions{}, []string{"ab", "世"}, []int{0, 0}},
+		{"showbreak", "abcdefgh", 6, WrapOptions{ShowBreak: ">"}, []string{"abcdef", "gh"}, []int{0, 1}},
+		{"breakindent", "  abcdefgh", 6, WrapOptions{BreakIndent: true, ShowBreak: ">"}, []string{"  abcd", "efg", "h"}, []int{0, 3, 3}},
+		{"an indent that is too deep", "      abcdefgh", 8, WrapOptions{BreakIndent: true}, []string{"      ab", "cdefgh"}, []int{0, 0}},
+	}
+	for _, tt := range tests {
+		row := BufRow(tt.row)
+		segs := row.Wrap(tt.width, tt.o)
+		var got []string
+		var x []int
+		for _, s := range segs {
+			got = append(got, string(row[s.Start:s.End]))
+			x = append(x, s.X)
+		}
+		if !slices.Equal(got, tt.want) || !slices.Equal(x, tt.x) {
+			t.Errorf("%s: got %q at %v, want %q at %v", tt.name, got, x, tt.want, tt.x)
+		}
+	}
+}
+
+func TestSegmentAt(t *testing.T) {
+	segs := BufRow("hello world").Wrap(4, WrapOptions{})
+	for x, want := range map[int]int{0: 0, 3: 0, 4: 1, 10: 2, 11: 2} {
+		if got := SegmentAt(segs, x); got != want {
+			t.Errorf("rune %d is in segment %d, want %d", x, got, want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// the jte command line
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// set when building a release, e.g.
+//
+//	go build -ldflags="-X github.com/jcocozza/jte/internal/cli.Version=v1.0.0"
+var Version = "dev"
+
+// exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1 // a file could not be opened, the terminal could not be set up, ...
+	ExitUsage = 2 // the command line was wrong
+)
+
+const usage = `usage: jte [options] [file ...]
+
+  -              read the text from stdin
+  +N             start on line N of the next file
+  +              start on the last line of the next file
+  +/pattern      start on the first match of pattern in the next file
+  file:line:col  start at line and column (in bytes) of file
+  -R             open the files read only
+  -o             open the files in splits, one above the other
+  -O             open the files in splits, side by side
+  -c cmd         run the ex command cmd once the files are open (can be repeated)
+  --headless     run the commands without a terminal, like ex -s, and exit
+  --script file  with --headless, run the commands in file after the -c commands
+  --version      print the version and exit
+  -h, --help     print this help and exit
+
+when stdout is a pipe, :wq writes the buffer to it, e.g. git diff | jte - | less
+
+the commands for --headless are ex commands: a range (N, ., $, %, /pattern/, +N, -N)
+followed by d, s/pattern/replacement/[gie], g/pattern/cmd, v/pattern/cmd, w, wq, q or q!.
+patterns are go regular expressions. a failed command stops the run with exit status 1,
+e.g. jte --headless -c 'g/TODO/d' -c wq main.go
+`
+
+func PrintUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+}
+
+type Split int
+
+const (
+	NoSplit    Split = iota
+	Horizontal       // -o
+	Vertical         // -O
+)
+
+// where to put the cursor in a file once it is open
+//
+// the zero value is the start of the file
+type Position struct {
+	Line int // 1 indexed, 0 when not set
+	Col  int // 1 indexed byte column, 0 when not set
+	// start on the last line
+	Last bool
+	// start on the first match
+	Pattern string
+}
+
+type File struct {
+	// "" for the buffer that is opened when no files are given
+	Path  string
+	Stdin bool
+	Pos   Position
+}
+
+type Options struct {
+	Files    []File
+	ReadOnly bool
+	Split    Split
+	Commands []string
+
+	Headless bool
+	// a file of commands for headless mode, one per line
+	Script string
+
+	Help    bool
+	Version bool
+}
+
+var ErrUsage = errors.New("usage")
+
+// parse the arguments (without the program name)
+//
+// errors wrap ErrUsage
+func Parse(args []string) (*Options, error) {
+	return parse(args, exists)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func usageErr(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, a...))
+}
+
+// exists is how file:line:col tells a position from a file that has a colon in its name
+func parse(args []string, exists func(string) bool) (*Options, error) {
+	o := &Options{}
+	var pos *Position
+	onlyFiles := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if onlyFiles {
+			o.addFile(arg, &pos, exists)
+			continue
+		}
+		switch {
+		case arg == "--":
+			onlyFiles = true
+		case arg == "-h" || arg == "--help":
+			o.Help = true
+		case arg == "--version":
+			o.Version = true
+		case arg == "-R":
+			o.ReadOnly = true
+		case arg == "-o":
+			o.Split = Horizontal
+		case arg == "-O":
+			o.Split = Vertical
+		case arg == "-c":
+			if i+1 >= len(args) {
+				return nil, usageErr("-c needs a command")
+			}
+			i++
+			o.Commands = append(o.Commands, args[i])
+		case arg == "--headless":
+			o.Headless = true
+		case arg == "--script":
+			if i+1 >= len(args) {
+				return nil, usageErr("--script needs a file")
+			}
+			i++
+			o.Script = args[i]
+		case arg == "-":
+			o.Files = append(o.Files, File{Stdin: true, Pos: take(&pos)})
+		case strings.HasPrefix(arg, "+"):
+			p, err := parsePosition(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			pos = &p
+		case strings.HasPrefix(arg, "-"):
+			return nil, usageErr("unknown option %s", arg)
+		default:
+			o.addFile(arg, &pos, exists)
+		}
+	}
+	if pos != nil {
+		if len(o.Files) > 0 {
+			return nil, usageErr("+%s must come before a file", positionArg(*pos))
+		}
+		o.Files = append(o.Files, File{Pos: *pos})
+	}
+	stdin := 0
+	for _, f := range o.Files {
+		if f.Stdin {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return nil, usageErr("- can only be given once")
+	}
+	if o.Script != "" && !o.Headless {
+		return nil, usageErr("--script needs --headless")
+	}
+	return o, nil
+}
+
+// one of the files is stdin
+func (o *Options) Stdin() bool {
+	for _, f := range o.Files {
+		if f.Stdin {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) addFile(arg string, pos **Position, exists func(string) bool) {
+	f := File{Path: arg}
+	if path, p, ok := splitPosition(arg); ok && !exists(arg) {
+		f.Path, f.Pos = path, p
+	}
+	if *pos != nil {
+		f.Pos = take(pos)
+	}
+	o.Files = append(o.Files, f)
+}
+
+func take(pos **Position) Position {
+	if *pos == nil {
+		return Position{}
+	}
+	p := **pos
+	*pos = nil
+	return p
+}
+
+// the part of a +arg after the +
+func parsePosition(s string) (Position, error) {
+	switch {
+	case s == "":
+		return Position{Last: true}, nil
+	case strings.HasPrefix(s, "/"):
+		if s == "/" {
+			return Position{}, usageErr("+/ needs a pattern")
+		}
+		return Position{Pattern: s[1:]}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return Position{}, usageErr("bad line number +%s", s)
+	}
+	return Position{Line: n}, nil
+}
+
+func positionArg(p Position) string {
+	switch {
+	case p.Last:
+		return ""
+	case p.Pattern != "":
+		return "/" + p.Pattern
+	}
+	return strconv.Itoa(p.Line)
+}
+
+// split file:line or file:line:col
+func splitPosition(arg string) (string, Position, bool) {
+	path, last, ok := cutLastNumber(arg)
+	if !ok {
+		return "", Position{}, false
+	}
+	if p, line, ok := cutLastNumber(path); ok {
+		return p, Position{Line: line, Col: last}, true
+	}
+	return path, Position{Line: last}, true
+}
+
+func cutLastNumber(s string) (string, int, bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 1 || i+1 == len(s) || strings.TrimLeft(s[i+1:], "0123456789") != "" {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return s[:i], n, true
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/editor"
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+func TestParse(t *testing.T) {
+	existing := map[string]bool{"a:1": true}
+	exists := func(path string) bool { return existing[path] }
+	tests := []struct {
+		name string
+		args []string
+		want *Options
+	}{
+		{
+			name: "no arguments",
+			args: nil,
+			want: &Options{},
+		},
+		{
+			name: "files",
+			args: []string{"a", "b"},
+			want: &Options{Files: []File{{Path: "a"}, {Path: "b"}}},
+		},
+		{
+			name: "line",
+			args: []string{"+3", "a", "b"},
+			want: &Options{Files: []File{{Path: "a", Pos: Position{Line: 3}}, {Path: "b"}}},
+		},
+		{
+			name: "last line",
+			args: []string{"a", "+", "b"},
+			want: &Options{Files: []File{{Path: "a"}, {Path: "b", Pos: Position{Last: true}}}},
+		},
+		{
+			name: "pattern",
+			args: []string{"+/func main", "main.go"},
+			want: &Options{Files: []File{{Path: "main.go", Pos: Position{Pattern: "func main"}}}},
+		},
+		{
+			name: "line and column",
+			args: []string{"main.go:12:5", "b.go:7"},
+			want: &Options{Files: []File{
+				{Path: "main.go", Pos: Position{Line: 12, Col: 5}},
+				{Path: "b.go", Pos: Position{Line: 7}},
+			}},
+		},
+		{
+			name: "a file with a colon in its name",
+			args: []string{"a:1", "b:", "c:x"},
+			want: &Options{Files: []File{{Path: "a:1"}, {Path: "b:"}, {Path: "c:x"}}},
+		},
+		{
+			name: "position without a file",
+			args: []string{"+5"},
+			want: &Options{Files: []File{{Pos: Position{Line: 5}}}},
+		},
+		{
+			name: "stdin",
+			args: []string{"+2", "-"},
+			want: &Options{Files: []File{{Stdin: true, Pos: Position{Line: 2}}}},
+		},
+		{
+			name: "options",
+			args: []string{"-R", "-O", "-c", "set mouse", "-c", "q", "a", "b"},
+			want: &Options{
+				Files:    []File{{Path: "a"}, {Path: "b"}},
+				ReadOnly: true,
+				Split:    Vertical,
+				Commands: []string{"set mouse", "q"},
+			},
+		},
+		{
+			name: "headless",
+			args: []string{"--headless", "--script", "fix.ex", "-c", "wq", "a"},
+			want: &Options{
+				Files:    []File{{Path: "a"}},
+				Commands: []string{"wq"},
+				Headless: true,
+				Script:   "fix.ex",
+			},
+		},
+		{
+			name: "files after --",
+			args: []string{"--", "-R", "+3"},
+			want: &Options{Files: []File{{Path: "-R"}, {Path: "+3"}}},
+		},
+		{
+			name: "help and version",
+			args: []string{"--help", "--version"},
+			want: &Options{Help: true, Version: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.args, exists)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := [][]string{
+		{"-x"},
+		{"-c"},
+		{"+0", "a"},
+		{"+abc", "a"},
+		{"+/"},
+		{"a", "+3"},
+		{"-", "-"},
+		{"--script", "fix.ex"},
+		{"--headless", "--script"},
+	}
+	for _, args := range tests {
+		_, err := Parse(args)
+		if !errors.Is(err, ErrUsage) {
+			t.Errorf("%q: error = %v, want a usage error", args, err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	if err := os.WriteFile(a, []byte("one\ntwo\nthe wörd\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b := filepath.Join(dir, "b")
+	if err := os.WriteFile(b, []byte("b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name  string
+		opts  Options
+		x, y  int
+		panes int
+		warn  bool
+	}{
+		{name: "line", opts: Options{Files: []File{{Path: a, Pos: Position{Line: 2}}}}, x: 0, y: 1, panes: 1},
+		{name: "line past the end", opts: Options{Files: []File{{Path: a, Pos: Position{Line: 99}}}}, x: 0, y: 3, panes: 1},
+		{name: "last line", opts: Options{Files: []File{{Path: a, Pos: Position{Last: true}}}}, x: 0, y: 3, panes: 1},
+		{name: "byte column", opts: Options{Files: []File{{Path: a, Pos: Position{Line: 3, Col: 8}}}}, x: 6, y: 2, panes: 1},
+		{name: "pattern", opts: Options{Files: []File{{Path: a, Pos: Position{Pattern: "w.r"}}}}, x: 4, y: 2, panes: 1},
+		{name: "pattern not found", opts: Options{Files: []File{{Path: a, Pos: Position{Pattern: "zzz"}}}}, panes: 1, warn: true},
+		{name: "files in splits", opts: Options{Files: []File{{Path: a}, {Path: b}, {Path: missing}}, Split: Horizontal}, panes: 3},
+		{name: "files without splits", opts: Options{Files: []File{{Path: a}, {Path: b}}}, panes: 1},
+	}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := editor.NewEditor(l)
+			warnings, err := tt.opts.Open(e, strings.NewReader(""), l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(warnings) > 0) != tt.warn {
+				t.Errorf("warnings = %v, want warnings %v", warnings, tt.warn)
+			}
+			buf := e.BM.Current.Buf
+			if buf.FilePath != a {
+				t.Errorf("current buffer = %q, want %q", buf.FilePath, a)
+			}
+			if buf.X() != tt.x || buf.Y() != tt.y {
+				t.Errorf("cursor = (%d, %d), want (%d, %d)", buf.X(), buf.Y(), tt.x, tt.y)
+			}
+			panes := 0
+			e.Root.Leaves(func(n *editor.SplitNode) { panes++ })
+			if panes != tt.panes {
+				t.Errorf("panes = %d, want %d", panes, tt.panes)
+			}
+			if !e.Active.Pane.Active || e.Active.Pane.Buf != buf {
+				t.Errorf("the active pane is not on the first file")
+			}
+			if got := len(e.BM.ListAll()); got != len(tt.opts.Files) {
+				t.Errorf("buffers = %d, want %d", got, len(tt.opts.Files))
+			}
+		})
+	}
+}
+
+func TestOpen_ReadOnlyStdin(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := editor.NewEditor(l)
+	o := Options{Files: []File{{Stdin: true}}, ReadOnly: true}
+	if _, err := o.Open(e, strings.NewReader("x\ny\n"), l); err != nil {
+		t.Fatal(err)
+	}
+	buf := e.BM.Current.Buf
+	if len(buf.Rows) != 2 || string(buf.Rows[1]) != "y" {
+		t.Errorf("rows = %q, want the text from stdin", buf.Rows)
+	}
+	if !buf.ReadOnly || buf.Name != noName {
+		t.Errorf("read only = %v, name = %q", buf.ReadOnly, buf.Name)
+	}
+}
+
+func TestOpen_FileType(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := editor.NewEditor(l)
+	o := Options{Files: []File{{Stdin: true}, {Path: filepath.Join(t.TempDir(), "new.go")}}, Split: Vertical}
+	if _, err := o.Open(e, strings.NewReader("#!/usr/bin/env python3\n"), l); err != nil {
+		t.Fatal(err)
+	}
+	var types []fileutil.FileType
+	e.Root.Leaves(func(n *editor.SplitNode) { types = append(types, n.Pane.Buf.FileType) })
+	if len(types) != 2 || types[0] != "python" || types[1] != "go" {
+		t.Errorf("file types = %q, want python from the first line and go from the name", types)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/editor"
+)
+
+// an ex command and where it came from, for error messages
+type Command struct {
+	Text string
+	// "-c" or the script file
+	Source string
+	// the line in the script, or which -c it was (1 indexed)
+	Line int
+}
+
+// an ex command that failed
+type CommandError struct {
+	Command Command
+	Err     error
+}
+
+func (c *CommandError) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %s", c.Command.Source, c.Command.Line, c.Command.Text, c.Err)
+}
+
+func (c *CommandError) Unwrap() error { return c.Err }
+
+var ErrNotWritten = errors.New("buffer modified but not written (end with wq or q!)")
+
+// the -c commands followed by the commands in the script
+//
+// empty lines and lines starting with " (comments) are skipped
+func (o *Options) HeadlessCommands() ([]Command, error) {
+	var cmds []Command
+	for i, c := range o.Commands {
+		cmds = append(cmds, Command{Text: c, Source: "-c", Line: i + 1})
+	}
+	if o.Script == "" {
+		return cmds, nil
+	}
+	f, err := os.Open(o.Script)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, `"`) {
+			continue
+		}
+		cmds = append(cmds, Command{Text: text, Source: o.Script, Line: line})
+	}
+	return cmds, sc.Err()
+}
+
+// run the commands without a terminal, like ex -s
+//
+// stops at the first command that fails. returns nil once a command exits the editor (e.g. wq or q!),
+// q with a buffer that has not been written fails like any command, and so does running out of
+// commands with one
+func RunHeadless(e *editor.Editor, cmds []Command) error {
+	for _, c := range cmds {
+		err := e.Execute(c.Text)
+		if errors.Is(err, editor.ErrExit) {
+			return nil
+		}
+		if err != nil {
+			return &CommandError{Command: c, Err: err}
+		}
+	}
+	for _, b := range e.BM.ListAll() {
+		if b.Modified {
+			return fmt.Errorf("%s: %w", b.BufName, ErrNotWritten)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/editor"
+)
+
+func TestHeadlessCommands(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script")
+	if err := os.WriteFile(script, []byte("\" drop the todos\ng/TODO/d\n\n  wq\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	o := Options{Commands: []string{"1d"}, Script: script}
+	got, err := o.HeadlessCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Command{
+		{Text: "1d", Source: "-c", Line: 1},
+		{Text: "g/TODO/d", Source: script, Line: 2},
+		{Text: "wq", Source: script, Line: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRunHeadless(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	open := func(t *testing.T) *editor.Editor {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("package main\n// TODO a\nvar foo = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		e := editor.NewEditor(l)
+		o := Options{Files: []File{{Path: path}}}
+		if _, err := o.Open(e, strings.NewReader(""), l); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	cmd := func(line int, text string) Command { return Command{Text: text, Source: "-c", Line: line} }
+
+	t.Run("writes the file", func(t *testing.T) {
+		e := open(t)
+		err := RunHeadless(e, []Command{cmd(1, "g/TODO/d"), cmd(2, "s/foo/bar/"), cmd(3, "wq"), cmd(4, "bogus")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := os.ReadFile(path)
+		if string(got) != "package main\nvar bar = 1\n" {
+			t.Errorf("file = %q", got)
+		}
+	})
+	t.Run("stops at the first error", func(t *testing.T) {
+		e := open(t)
+		err := RunHeadless(e, []Command{cmd(1, "g/TODO/d"), cmd(2, "s/nope/x/"), cmd(3, "wq")})
+		var ce *CommandError
+		if !errors.As(err, &ce) || ce.Command.Line != 2 {
+			t.Fatalf("error = %v, want an error for -c 2", err)
+		}
+		if !strings.HasPrefix(err.Error(), "-c:2: s/nope/x/: ") {
+			t.Errorf("error = %q", err)
+		}
+		got, _ := os.ReadFile(path)
+		if string(got) != "package main\n// TODO a\nvar foo = 1\n" {
+			t.Errorf("file was written: %q", got)
+		}
+	})
+	t.Run("q with changes that are not written", func(t *testing.T) {
+		e := open(t)
+		err := RunHeadless(e, []Command{cmd(1, "g/TODO/d"), cmd(2, "q")})
+		var ce *CommandError
+		if !errors.As(err, &ce) || ce.Command.Line != 2 || !errors.Is(err, editor.ErrNotWritten) {
+			t.Errorf("error = %v, want an error for -c 2", err)
+		}
+	})
+	t.Run("q! drops the changes", func(t *testing.T) {
+		e := open(t)
+		if err := RunHeadless(e, []Command{cmd(1, "g/TODO/d"), cmd(2, "q!")}); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := os.ReadFile(path)
+		if string(got) != "package main\n// TODO a\nvar foo = 1\n" {
+			t.Errorf("file was written: %q", got)
+		}
+	})
+	t.Run("changes that are not written", func(t *testing.T) {
+		e := open(t)
+		if err := RunHeadless(e, []Command{cmd(1, "1d")}); !errors.Is(err, ErrNotWritten) {
+			t.Errorf("error = %v, want ErrNotWritten", err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"regexp"
+
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/editor"
+	"github.com/jcocozza/jte/internal/gutter"
+	"github.com/jcocozza/jte/internal/syntax"
+)
+
+// the name of a buffer that is not backed by a file
+const noName = "[No Name]"
+
+// open the files into the editor and lay out its panes
+//
+// a file that does not exist yet is opened as an empty buffer, it is created when it is written.
+// returns the problems that should not stop the editor (e.g. a pattern that is not found)
+func (o *Options) Open(e *editor.Editor, stdin io.Reader, l *slog.Logger) ([]error, error) {
+	files := o.Files
+	if len(files) == 0 {
+		files = []File{{}}
+	}
+	var warnings []error
+	var panes []*editor.Pane
+	for _, f := range files {
+		buf, err := load(f, stdin, l)
+		if err != nil {
+			return nil, err
+		}
+		if o.ReadOnly {
+			buf.ReadOnly = true
+		}
+		buf.FileType = syntax.Detect(buf.FilePath, string(buf.Rows[0]))
+		if err := f.Pos.move(buf); err != nil {
+			warnings = append(warnings, fmt.Errorf("%s: %w", buf.Name, err))
+		}
+		e.BM.Add(buf)
+		if len(panes) == 0 || o.Split != NoSplit {
+			panes = append(panes, &editor.Pane{Buf: buf, G: gutter.NewGutter()})
+		}
+	}
+	dir := editor.Horizontal
+	if o.Split == Vertical {
+		dir = editor.Vertical
+	}
+	e.Root = editor.NewLayout(dir, panes...)
+	var first *editor.SplitNode
+	e.Root.Leaves(func(n *editor.SplitNode) {
+		if first == nil {
+			first = n
+		}
+	})
+	e.Focus(first)
+	return warnings, nil
+}
+
+func load(f File, stdin io.Reader, l *slog.Logger) (*buffer.Buffer, error) {
+	switch {
+	case f.Stdin:
+		buf, err := buffer.ReadIntoBuffer(noName, stdin, l)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read stdin: %w", err)
+		}
+		return buf, nil
+	case f.Path == "":
+		return buffer.NewBuffer(noName, "", false, []buffer.BufRow{{}}, l), nil
+	}
+	buf, err := buffer.ReadFileIntoBuffer(f.Path, l)
+	if errors.Is(err, fs.ErrNotExist) {
+		return buffer.NewBuffer(f.Path, f.Path, false, []buffer.BufRow{{}}, l), nil
+	}
+	return buf, err
+}
+
+// put the cursor of buf at p
+func (p Position) move(buf *buffer.Buffer) error {
+	switch {
+	case p.Last:
+		buf.SetCursor(0, len(buf.Rows)-1)
+	case p.Pattern != "":
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("bad pattern: %w", err)
+		}
+		for y, row := range buf.Rows {
+			if loc := re.FindStringIndex(string(row)); loc != nil {
+				buf.SetCursor(len([]rune(string(row)[:loc[0]])), y)
+				return nil
+			}
+		}
+		return fmt.Errorf("pattern not found: %s", p.Pattern)
+	case p.Line > 0:
+		y := min(p.Line, len(buf.Rows)) - 1
+		x := 0
+		if p.Col > 0 {
+			// the column is in bytes, like compilers and grep report it
+			s := string(buf.Rows[y])
+			x = len([]rune(s[:min(p.Col-1, len(s))]))
+		}
+		buf.SetCursor(x, y)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package display
+
+import "strings"
+
+// how many colors a terminal can show
+type ColorMode int
+
+const (
+	// 24 bit colors, colors are sent as they are
+	TrueColor ColorMode = iota
+	// the 256 color palette
+	Colors256
+	// the 16 basic and bright colors
+	Colors16
+)
+
+// the colors the terminal supports, from its environment
+//
+// COLORTERM=truecolor (or 24bit) is how terminals say they take 24 bit colors, a TERM like
+// xterm-256color has the 256 color palette, and anything else is assumed to have 16
+func DetectColorMode(getenv func(string) string) ColorMode {
+	switch getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	term := getenv("TERM")
+	switch {
+	case strings.HasSuffix(term, "-direct"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return Colors256
+	}
+	return Colors16
+}
+
+// the 16 basic and bright colors, the way xterm shows them
+var basicColors = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// the levels of red, green and blue in the 6x6x6 color cube of the palette (16-231)
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// the red, green and blue of palette color n
+func paletteRGB(n uint8) [3]uint8 {
+	switch {
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		return [3]uint8{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	}
+	// the grays
+	v := 8 + 10*(n-232)
+	return [3]uint8{v, v, v}
+}
+
+func distance(a, b [3]uint8) int {
+	d := 0
+	for i := range a {
+		x := int(a[i]) - int(b[i])
+		d += x * x
+	}
+	return d
+}
+
+// the palette color closest to rgb, of the colors from first to last
+func nearest(rgb [3]uint8, first, last int) uint8 {
+	best, bestDist := first, -1
+	for n := first; n <= last; n++ {
+		if d := distance(rgb, paletteRGB(uint8(n))); bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return uint8(best)
+}
+
+// the color closest to c that a terminal can show in mode
+func (c Color) In(mode ColorMode) Color {
+	if mode == TrueColor || c == DefaultColor {
+		return c
+	}
+	var rgb [3]uint8
+	if n, ok := c.Index(); ok {
+		if n < 16 || mode == Colors256 {
+			return c
+		}
+		rgb = paletteRGB(n)
+	} else if r, g, b, ok := c.RGB(); ok {
+		rgb = [3]uint8{r, g, b}
+	} else {
+		return c
+	}
+	if mode == Colors16 {
+		return Indexed(nearest(rgb, 0, 15))
+	}
+	// the basic colors are left out, terminals change them
+	return Indexed(nearest(rgb, 16, 255))
+}
+
+// s with its colors the closest ones a terminal can show in mode
+func (s Style) In(mode ColorMode) Style {
+	s.Fg, s.Bg = s.Fg.In(mode), s.Bg.In(mode)
+	return s
+}
+
+// whether an rgb background is light, so text on it should be dark
+func IsLight(r, g, b uint8) bool {
+	// the perceived brightness, ITU-R BT.601
+	return 299*int(r)+587*int(g)+114*int(b) > 128*1000
+}
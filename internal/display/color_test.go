@@ -0,0 +1,66 @@
+package display
+
+import "testing"
+
+func TestDetectColorMode(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorMode
+	}{
+		{"truecolor", "xterm-256color", TrueColor},
+		{"24bit", "", TrueColor},
+		{"", "xterm-direct", TrueColor},
+		{"", "xterm-256color", Colors256},
+		{"", "screen-256color", Colors256},
+		{"", "xterm", Colors16},
+		{"", "linux", Colors16},
+		{"", "", Colors16},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"COLORTERM": tt.colorterm, "TERM": tt.term}
+		if got := DetectColorMode(func(k string) string { return env[k] }); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: got %d, want %d", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestColor_In(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Color
+		mode ColorMode
+		want Color
+	}{
+		{"true color is left alone", RGB(1, 2, 3), TrueColor, RGB(1, 2, 3)},
+		{"the default color", DefaultColor, Colors16, DefaultColor},
+		{"a basic color", Indexed(3), Colors16, Indexed(3)},
+		{"a palette color in 256", Indexed(208), Colors256, Indexed(208)},
+		{"a palette color in 16", Indexed(196), Colors16, Indexed(9)},
+		{"a gray in 16", Indexed(244), Colors16, Indexed(8)},
+		{"rgb on the cube", RGB(255, 135, 0), Colors256, Indexed(208)},
+		{"rgb gray", RGB(0x80, 0x80, 0x80), Colors256, Indexed(244)},
+		{"rgb near black", RGB(0x00, 0x2b, 0x36), Colors256, Indexed(234)},
+		{"rgb in 16", RGB(0xdc, 0x32, 0x2f), Colors16, Indexed(1)},
+	}
+	for _, tt := range tests {
+		if got := tt.c.In(tt.mode); got != tt.want {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScreen_Colors(t *testing.T) {
+	s := Screen{Colors: Colors256}
+	s.Draw(nil, gridOf("a"))
+	g := gridOf("a")
+	g.Set(0, 0, Cell{Text: "a", Width: 1, Style: Style{Fg: RGB(255, 135, 0)}})
+	if got, want := string(s.Draw(nil, g)), "\x1b[1;1H\x1b[0;38;5;208ma\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIsLight(t *testing.T) {
+	if IsLight(0, 0x2b, 0x36) || !IsLight(0xfd, 0xf6, 0xe3) || IsLight(0, 0, 0) || !IsLight(255, 255, 255) {
+		t.Error("light and dark backgrounds are mixed up")
+	}
+}
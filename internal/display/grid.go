@@ -0,0 +1,194 @@
+// a model of the terminal screen as a grid of cells
+package display
+
+import "github.com/jcocozza/jte/internal/grapheme"
+
+// text attributes, they can be combined
+type Attr uint8
+
+const (
+	Bold Attr = 1 << iota
+	Dim
+	Italic
+	Underline
+	Reverse
+	Strikethrough
+)
+
+// a terminal color
+//
+// the zero value is the terminal's own foreground or background
+type Color uint32
+
+const (
+	DefaultColor Color = 0
+
+	indexed Color = 1 << 24
+	rgb     Color = 2 << 24
+)
+
+// one of the 256 colors of the terminal's palette (0-15 are the basic and bright colors)
+func Indexed(n uint8) Color {
+	return indexed | Color(n)
+}
+
+// a 24 bit color
+func RGB(r, g, b uint8) Color {
+	return rgb | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// the palette index, false if c is not a palette color
+func (c Color) Index() (uint8, bool) {
+	return uint8(c), c&^0xFFFFFF == indexed
+}
+
+// the red, green and blue of c, false if c is not a 24 bit color
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), c&^0xFFFFFF == rgb
+}
+
+type Style struct {
+	Fg, Bg Color
+	Attrs  Attr
+}
+
+// one column of the screen
+type Cell struct {
+	// the grapheme cluster drawn in the cell, "" for the column covered by a wide character to the left
+	Text string
+	// 1, or 2 for a wide character (0 for the column it covers)
+	Width int
+	Style Style
+}
+
+// the text for a cell that shows the grapheme cluster
+//
+// a mark with nothing to combine with is shown on a space
+func CellText(cluster []rune) string {
+	if len(cluster) > 0 && grapheme.RuneWidth(cluster[0]) == 0 {
+		return " " + string(cluster)
+	}
+	return string(cluster)
+}
+
+// a blank cell
+var Blank = Cell{Text: " ", Width: 1}
+
+// the cells of a screen, row by row
+type Grid struct {
+	rows, cols int
+	cells      []Cell
+}
+
+// a grid of blank cells
+func NewGrid(rows, cols int) *Grid {
+	g := &Grid{rows: rows, cols: cols, cells: make([]Cell, rows*cols)}
+	for i := range g.cells {
+		g.cells[i] = Blank
+	}
+	return g
+}
+
+func (g *Grid) Size() (rows int, cols int) {
+	return g.rows, g.cols
+}
+
+// put c at x, y, a wide character also covers the column to its right
+//
+// cells off the grid are ignored. a wide character that does not fit is drawn as a blank, and
+// a wide character that is partly written over is blanked
+func (g *Grid) Set(x, y int, c Cell) {
+	g.set(x, y, c, g.cols)
+}
+
+// limit is the column a wide character must end before
+func (g *Grid) set(x, y int, c Cell, limit int) {
+	if x < 0 || x >= g.cols || y < 0 || y >= g.rows {
+		return
+	}
+	if c.Width == 2 && x+1 >= limit {
+		c = Cell{Text: " ", Width: 1, Style: c.Style}
+	}
+	row := g.row(y)
+	// do not leave half of a wide character behind
+	if row[x].Width == 0 && x > 0 {
+		row[x-1] = Cell{Text: " ", Width: 1, Style: row[x-1].Style}
+	}
+	if row[x].Width == 2 && x+1 < g.cols {
+		row[x+1] = Cell{Text: " ", Width: 1, Style: row[x+1].Style}
+	}
+	row[x] = c
+	if c.Width == 2 {
+		if row[x+1].Width == 2 && x+2 < g.cols {
+			row[x+2] = Cell{Text: " ", Width: 1, Style: row[x+2].Style}
+		}
+		row[x+1] = Cell{Style: c.Style}
+	}
+}
+
+// a blank cell if x, y is off the grid
+func (g *Grid) Cell(x, y int) Cell {
+	if x < 0 || x >= g.cols || y < 0 || y >= g.rows {
+		return Blank
+	}
+	return g.cells[y*g.cols+x]
+}
+
+func (g *Grid) row(y int) []Cell {
+	return g.cells[y*g.cols : (y+1)*g.cols]
+}
+
+// a rectangle of the grid, for drawing a part of the screen (e.g. a pane)
+func (g *Grid) View(x, y, rows, cols int) *View {
+	return &View{g: g, x: x, y: y, rows: max(rows, 0), cols: max(cols, 0)}
+}
+
+// a rectangle of a grid, drawing outside of it is clipped
+//
+// coordinates are relative to the top left of the view
+type View struct {
+	g          *Grid
+	x, y       int
+	rows, cols int
+}
+
+// a rectangle of the view, it is clipped to the view
+func (v *View) View(x, y, rows, cols int) *View {
+	x, y = min(max(x, 0), v.cols), min(max(y, 0), v.rows)
+	return v.g.View(v.x+x, v.y+y, min(rows, v.rows-y), min(cols, v.cols-x))
+}
+
+func (v *View) Size() (rows int, cols int) {
+	return v.rows, v.cols
+}
+
+// put c at x, y in the view
+func (v *View) Set(x, y int, c Cell) {
+	if x < 0 || x >= v.cols || y < 0 || y >= v.rows {
+		return
+	}
+	v.g.set(v.x+x, v.y+y, c, v.x+v.cols)
+}
+
+// write s at x, y in style
+//
+// returns the column after the last one written
+func (v *View) Print(x, y int, s string, style Style) int {
+	rs := []rune(s)
+	for len(rs) > 0 && x < v.cols {
+		n, w := grapheme.Next(rs)
+		if w > 0 {
+			v.Set(x, y, Cell{Text: CellText(rs[:n]), Width: w, Style: style})
+			x += w
+		}
+		rs = rs[n:]
+	}
+	return min(x, v.cols)
+}
+
+// blank the columns [from, cols) of row y in style
+func (v *View) Fill(y, from int, style Style) {
+	for x := from; x < v.cols; x++ {
+		v.Set(x, y, Cell{Text: " ", Width: 1, Style: style})
+	}
+}
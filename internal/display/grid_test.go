@@ -0,0 +1,64 @@
+package display
+
+import "testing"
+
+func TestGrid_WideCharacters(t *testing.T) {
+	wide := Cell{Text: "世", Width: 2}
+	x := Cell{Text: "x", Width: 1}
+	tests := []struct {
+		name string
+		draw func(g *Grid)
+		want []Cell
+	}{
+		{
+			name: "covers the next column",
+			draw: func(g *Grid) { g.Set(1, 0, wide) },
+			want: []Cell{Blank, wide, {}, Blank},
+		},
+		{
+			name: "does not fit at the end of the row",
+			draw: func(g *Grid) { g.Set(3, 0, wide) },
+			want: []Cell{Blank, Blank, Blank, Blank},
+		},
+		{
+			name: "writing over the covered column blanks the character",
+			draw: func(g *Grid) { g.Set(1, 0, wide); g.Set(2, 0, x) },
+			want: []Cell{Blank, Blank, x, Blank},
+		},
+		{
+			name: "writing over the character blanks the covered column",
+			draw: func(g *Grid) { g.Set(1, 0, wide); g.Set(1, 0, x) },
+			want: []Cell{Blank, x, Blank, Blank},
+		},
+		{
+			name: "clipped to a view",
+			draw: func(g *Grid) {
+				v := g.View(1, 0, 1, 2)
+				v.Set(1, 0, wide)
+				v.Set(2, 0, x)
+			},
+			want: []Cell{Blank, Blank, Blank, Blank},
+		},
+		{
+			name: "printed into a view",
+			draw: func(g *Grid) {
+				v := g.View(1, 0, 1, 2)
+				if end := v.Print(0, 0, "x世y", Style{}); end != 2 {
+					t.Errorf("print ended at %d, want 2", end)
+				}
+			},
+			want: []Cell{Blank, x, Blank, Blank},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGrid(1, 4)
+			tt.draw(g)
+			for i, want := range tt.want {
+				if got := g.Cell(i, 0); got != want {
+					t.Errorf("cell %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
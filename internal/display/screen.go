@@ -0,0 +1,169 @@
+package display
+
+import "strconv"
+
+// rewriting this many unchanged cells is cheaper than moving the cursor over them
+const maxGap = 4
+
+// draws frames on a terminal, sending only the cells that changed since the last frame
+type Screen struct {
+	// the colors the terminal can show, the colors of the cells are changed to the closest of them
+	Colors ColorMode
+	// what the terminal shows, nil when it is not known
+	prev  *Grid
+	stats Stats
+}
+
+// what drawing has cost, to check the savings on slow links
+type Stats struct {
+	Frames       int
+	FullRepaints int
+	// the bytes of the last frame, and of all of them
+	LastBytes  int
+	TotalBytes int
+}
+
+// repaint the whole screen in the next frame (e.g. Ctrl-L, or once the terminal was used by something else)
+func (s *Screen) Invalidate() {
+	s.prev = nil
+}
+
+func (s *Screen) Stats() Stats {
+	return s.stats
+}
+
+// append the escape sequences that turn the last frame into g to out
+//
+// the whole screen is repainted when it was invalidated or its size changed.
+// the cursor is left wherever the last change was written
+func (s *Screen) Draw(out []byte, g *Grid) []byte {
+	start := len(out)
+	prev := s.prev
+	if prev == nil || prev.rows != g.rows || prev.cols != g.cols {
+		// cleared to blank cells, so only the rest has to be written
+		out = append(out, "\x1b[0m\x1b[2J"...)
+		prev = NewGrid(g.rows, g.cols)
+		s.stats.FullRepaints++
+	}
+	var cur Style
+	for y := 0; y < g.rows; y++ {
+		out = drawRow(out, y, g.row(y), prev.row(y), &cur, s.Colors)
+	}
+	if cur != (Style{}) {
+		out = append(out, "\x1b[0m"...)
+	}
+	s.prev = g
+	s.stats.Frames++
+	s.stats.LastBytes = len(out) - start
+	s.stats.TotalBytes += s.stats.LastBytes
+	return out
+}
+
+// write the runs of cells that changed in row y, in the colors of mode
+func drawRow(out []byte, y int, row []Cell, prev []Cell, cur *Style, mode ColorMode) []byte {
+	cursor := -1 // the column the terminal cursor is in, if it is in this row
+	x := 0
+	for x < len(row) {
+		if row[x] == prev[x] {
+			x++
+			continue
+		}
+		start := x
+		// a wide character is written from its first column
+		for start > 0 && row[start].Width == 0 {
+			start--
+		}
+		end := x + 1
+		for end < len(row) {
+			if row[end].Width == 0 || row[end] != prev[end] {
+				end++
+				continue
+			}
+			next := end
+			for next < len(row) && next-end < maxGap && row[next] == prev[next] {
+				next++
+			}
+			if next == len(row) || row[next] == prev[next] {
+				break
+			}
+			end = next
+		}
+		if start != cursor {
+			out = moveTo(out, start, y)
+		}
+		for _, c := range row[start:end] {
+			if c.Width == 0 {
+				continue
+			}
+			if c.Style != *cur {
+				out = setStyle(out, c.Style.In(mode))
+				*cur = c.Style
+			}
+			out = append(out, c.Text...)
+		}
+		cursor = end
+		x = end
+	}
+	return out
+}
+
+// x and y are 0 indexed
+func moveTo(out []byte, x, y int) []byte {
+	out = append(out, "\x1b["...)
+	out = strconv.AppendInt(out, int64(y+1), 10)
+	out = append(out, ';')
+	out = strconv.AppendInt(out, int64(x+1), 10)
+	return append(out, 'H')
+}
+
+// the SGR parameter of each attribute
+var attrCodes = []struct {
+	attr Attr
+	code string
+}{
+	{Bold, ";1"},
+	{Dim, ";2"},
+	{Italic, ";3"},
+	{Underline, ";4"},
+	{Reverse, ";7"},
+	{Strikethrough, ";9"},
+}
+
+// SGR, from the default style
+func setStyle(out []byte, s Style) []byte {
+	out = append(out, "\x1b[0"...)
+	for _, a := range attrCodes {
+		if s.Attrs&a.attr != 0 {
+			out = append(out, a.code...)
+		}
+	}
+	out = appendColor(out, s.Fg, 30)
+	out = appendColor(out, s.Bg, 40)
+	return append(out, 'm')
+}
+
+// base is 30 for the foreground and 40 for the background
+func appendColor(out []byte, c Color, base int) []byte {
+	if n, ok := c.Index(); ok {
+		switch {
+		case n < 8:
+			return strconv.AppendInt(append(out, ';'), int64(base+int(n)), 10)
+		case n < 16:
+			// the bright colors
+			return strconv.AppendInt(append(out, ';'), int64(base+60+int(n)-8), 10)
+		}
+		out = strconv.AppendInt(append(out, ';'), int64(base+8), 10)
+		out = append(out, ";5;"...)
+		return strconv.AppendInt(out, int64(n), 10)
+	}
+	if r, g, b, ok := c.RGB(); ok {
+		out = strconv.AppendInt(append(out, ';'), int64(base+8), 10)
+		out = append(out, ";2;"...)
+		out = strconv.AppendInt(out, int64(r), 10)
+		out = append(out, ';')
+		out = strconv.AppendInt(out, int64(g), 10)
+		out = append(out, ';')
+		return strconv.AppendInt(out, int64(b), 10)
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package display
+
+import (
+	"strings"
+	"testing"
+)
+
+func gridOf(rows ...string) *Grid {
+	g := NewGrid(len(rows), len([]rune(rows[0])))
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			g.Set(x, y, Cell{Text: string(r), Width: 1})
+		}
+	}
+	return g
+}
+
+func TestScreen_Draw(t *testing.T) {
+	tests := []struct {
+		name string
+		prev *Grid
+		next *Grid
+		want string
+	}{
+		{
+			name: "first frame",
+			next: gridOf("ab  ", "  c "),
+			want: "\x1b[0m\x1b[2J\x1b[1;1Hab\x1b[2;3Hc",
+		},
+		{
+			name: "nothing changed",
+			prev: gridOf("abcd"),
+			next: gridOf("abcd"),
+			want: "",
+		},
+		{
+			name: "one cell",
+			prev: gridOf("abcd", "efgh"),
+			next: gridOf("abcd", "efXh"),
+			want: "\x1b[2;3HX",
+		},
+		{
+			name: "short gaps are written over",
+			prev: gridOf("abcdefghijklmn"),
+			next: gridOf("XbcdXfghijklmX"),
+			want: "\x1b[1;1HXbcdX\x1b[1;14HX",
+		},
+		{
+			name: "size changed",
+			prev: gridOf("ab"),
+			next: gridOf("ab", "cd"),
+			want: "\x1b[0m\x1b[2J\x1b[1;1Hab\x1b[2;1Hcd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Screen
+			if tt.prev != nil {
+				s.Draw(nil, tt.prev)
+			}
+			got := string(s.Draw(nil, tt.next))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if s.Stats().LastBytes != len(tt.want) {
+				t.Errorf("last bytes = %d, want %d", s.Stats().LastBytes, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestScreen_Styles(t *testing.T) {
+	var s Screen
+	s.Draw(nil, gridOf("abc"))
+	g := gridOf("abc")
+	g.Set(1, 0, Cell{Text: "b", Width: 1, Style: Style{Attrs: Reverse}})
+	if got, want := string(s.Draw(nil, g)), "\x1b[1;2H\x1b[0;7mb\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScreen_WideCharacters(t *testing.T) {
+	var s Screen
+	s.Draw(nil, gridOf("abcd"))
+	g := gridOf("abcd")
+	g.Set(1, 0, Cell{Text: "世", Width: 2})
+	if got, want := string(s.Draw(nil, g)), "\x1b[1;2H世"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScreen_Invalidate(t *testing.T) {
+	var s Screen
+	g := gridOf("ab")
+	s.Draw(nil, g)
+	s.Invalidate()
+	if got := string(s.Draw(nil, g)); !strings.HasPrefix(got, "\x1b[0m\x1b[2J") {
+		t.Errorf("got %q, want a full repaint", got)
+	}
+	stats := s.Stats()
+	if stats.Frames != 2 || stats.FullRepaints != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestSetStyle(t *testing.T) {
+	tests := []struct {
+		style Style
+		want  string
+	}{
+		{Style{}, "\x1b[0m"},
+		{Style{Attrs: Bold | Underline}, "\x1b[0;1;4m"},
+		{Style{Fg: Indexed(1), Bg: Indexed(12)}, "\x1b[0;31;104m"},
+		{Style{Fg: Indexed(208)}, "\x1b[0;38;5;208m"},
+		{Style{Fg: RGB(1, 2, 3), Bg: RGB(255, 0, 128)}, "\x1b[0;38;2;1;2;3;48;2;255;0;128m"},
+	}
+	for _, tt := range tests {
+		if got := string(setStyle(nil, tt.style)); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package editor
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/keyboard"
 	"github.com/jcocozza/jte/internal/mode"
 )
 
@@ -15,8 +17,35 @@ type Action interface {
 	Apply(e *Editor) error
 }
 
+// an action that takes the count itself instead of being applied count times
+//
+// count is 0 when no count was given
+type CountedAction interface {
+	Action
+	WithCount(count int) Action
+}
+
+// a failed action stops the command that is running (and any macro that is playing)
+//
+// unlike other errors, it does not exit the editor
+var ErrFailed = errors.New("failed")
+
+var ErrFailedMotion = fmt.Errorf("motion %w", ErrFailed)
+
 // exit
 
+// stop the editor and go back to the shell until it is continued (Ctrl-Z)
+type Suspend struct{}
+
+func (a Suspend) String() string        { return "suspend" }
+func (a Suspend) Apply(e *Editor) error { e.suspend = true; return nil }
+
+// repaint the whole screen (Ctrl-L)
+type Redraw struct{}
+
+func (a Redraw) String() string        { return "redraw" }
+func (a Redraw) Apply(e *Editor) error { e.redraw = true; return nil }
+
 var ErrExit = errors.New("exit")
 
 type Exit struct{}
@@ -37,10 +66,16 @@ func (a SwitchMode) Apply(e *Editor) error {
 	switch a.m {
 	case mode.Insert:
 		e.BM.Current.Buf.StartEvent(buffer.Event_Insert)
+	case mode.Replace:
+		e.BM.Current.Buf.StartEvent(buffer.Event_Replace)
+		e.replaced = nil
 	case mode.Normal:
 		e.BM.Current.Buf.Commit()
 	case mode.Command:
-
+		e.cmdline = nil
+	case mode.Visual:
+		e.BM.Current.Buf.Commit()
+		e.visual = buffer.Cursor{X: e.BM.Current.Buf.X(), Y: e.BM.Current.Buf.Y()}
 	default:
 		panic("nothing to do there")
 	}
@@ -50,25 +85,77 @@ func (a SwitchMode) Apply(e *Editor) error {
 
 // navigation
 
+func motion(moved bool) error {
+	if !moved {
+		return ErrFailedMotion
+	}
+	return nil
+}
+
 type CursorUp struct{}
 
 func (a CursorUp) String() string        { return "CursorUp" }
-func (a CursorUp) Apply(e *Editor) error { e.BM.Current.Buf.Up(); return nil }
+func (a CursorUp) Apply(e *Editor) error { return motion(e.BM.Current.Buf.Up()) }
 
 type CursorDown struct{}
 
 func (a CursorDown) String() string        { return "CursorDown" }
-func (a CursorDown) Apply(e *Editor) error { e.BM.Current.Buf.Down(); return nil }
+func (a CursorDown) Apply(e *Editor) error { return motion(e.BM.Current.Buf.Down()) }
 
 type CursorLeft struct{}
 
 func (a CursorLeft) String() string        { return "CursorLeft" }
-func (a CursorLeft) Apply(e *Editor) error { e.BM.Current.Buf.Left(); return nil }
+func (a CursorLeft) Apply(e *Editor) error { return motion(e.BM.Current.Buf.Left()) }
 
 type CursorRight struct{}
 
 func (a CursorRight) String() string        { return "CursorRight" }
-func (a CursorRight) Apply(e *Editor) error { e.BM.Current.Buf.Right(); return nil }
+func (a CursorRight) Apply(e *Editor) error { return motion(e.BM.Current.Buf.Right()) }
+
+// visual
+
+// delete the selection and leave visual mode
+type DeleteSelection struct{}
+
+func (a DeleteSelection) String() string { return "delete selection" }
+func (a DeleteSelection) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	sel := e.Selection()
+	if sel == nil {
+		return nil
+	}
+	if err := e.regs.Set(e.takeRegister(), sel.Register(buf)); err != nil {
+		return err
+	}
+	end := sel.End
+	if sel.Start.Y == end.Y {
+		end.X = min(end.X+1, len(buf.Rows[end.Y]))
+	}
+	c := buffer.DeleteAt{StartCur: sel.Start, EndCur: end}
+	if err := buf.StartAndAcceptChange(c, buffer.Event_Delete); err != nil {
+		return err
+	}
+	buf.Commit()
+	buf.SetCursor(sel.Start.X, sel.Start.Y)
+	return SwitchMode{m: mode.Normal}.Apply(e)
+}
+
+// yank the selection and leave visual mode
+type YankSelection struct{}
+
+func (a YankSelection) String() string { return "yank selection" }
+func (a YankSelection) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	sel := e.Selection()
+	if sel == nil {
+		return nil
+	}
+	if err := e.regs.Set(e.takeRegister(), sel.Register(buf)); err != nil {
+		return err
+	}
+	buf.SetCursor(sel.Start.X, sel.Start.Y)
+	return SwitchMode{m: mode.Normal}.Apply(e)
+}
 
 // splits
 
@@ -76,9 +163,8 @@ type SplitVertical struct{}
 
 func (a SplitVertical) String() string { return "vert split" }
 func (a SplitVertical) Apply(e *Editor) error {
-	e.Active.Pane.Active = false
-	e.Active = e.Active.SplitVertical()
-	e.Active.Pane.Active = true
+	e.unzoom()
+	e.Focus(e.Active.SplitVertical())
 	return nil
 }
 
@@ -86,9 +172,8 @@ type SplitHorizontal struct{}
 
 func (a SplitHorizontal) String() string { return "horizontal split" }
 func (a SplitHorizontal) Apply(e *Editor) error {
-	e.Active.Pane.Active = false
-	e.Active = e.Active.SplitHorizontal()
-	e.Active.Pane.Active = true
+	e.unzoom()
+	e.Focus(e.Active.SplitHorizontal())
 	return nil
 }
 
@@ -107,6 +192,105 @@ func (a Insert) Apply(e *Editor) error {
 	return e.BM.Current.Buf.AcceptChange(c)
 }
 
+// insert pasted text as a single change, in replace mode it types over the text
+//
+// the paste is its own undo step, even in the middle of an insert
+type Paste struct{ text string }
+
+func (a Paste) String() string { return fmt.Sprintf("paste (%d bytes)", len(a.text)) }
+func (a Paste) Apply(e *Editor) error {
+	text := keyboard.NormalizeNewlines(a.text)
+	buf := e.BM.Current.Buf
+	buf.Commit()
+	var err error
+	switch e.m.Current() {
+	case mode.Replace:
+		buf.StartEvent(buffer.Event_Replace)
+		err = a.typeOver(e, text)
+		buf.Commit()
+		buf.StartEvent(buffer.Event_Replace)
+	default:
+		lines := strings.Split(text, "\n")
+		contents := make([][]rune, len(lines))
+		for i, l := range lines {
+			contents[i] = []rune(l)
+		}
+		buf.StartEvent(buffer.Event_Insert)
+		err = buf.AcceptChange(buffer.Insert{Contents: contents})
+		buf.Commit()
+		if e.m.Current() == mode.Insert {
+			buf.StartEvent(buffer.Event_Insert)
+		}
+	}
+	return err
+}
+
+// the text as if it had been typed in replace mode, so backspace puts back what it typed over
+func (a Paste) typeOver(e *Editor, text string) error {
+	for _, c := range text {
+		var r Action = ReplaceChar{c: c}
+		if c == '\n' {
+			r = ReplaceNewLine{}
+		}
+		if err := r.Apply(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// type over the character under the cursor, at the end of the line the character is added
+type ReplaceChar struct{ c rune }
+
+func (a ReplaceChar) String() string { return fmt.Sprintf("replace: %s", string(a.c)) }
+func (a ReplaceChar) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	orig := rune(-1)
+	if y := buf.Y(); y < len(buf.Rows) && buf.X() < len(buf.Rows[y]) {
+		orig = buf.Rows[y][buf.X()]
+		if err := buf.AcceptChange(buffer.Delete{}); err != nil {
+			return err
+		}
+	}
+	e.replaced = append(e.replaced, orig)
+	return buf.AcceptChange(buffer.Insert{Contents: [][]rune{{a.c}}})
+}
+
+// put back the last character typed over in replace mode
+//
+// before the text that was typed, it only moves the cursor
+type ReplaceBackspace struct{}
+
+func (a ReplaceBackspace) String() string { return "replace backspace" }
+func (a ReplaceBackspace) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	if len(e.replaced) == 0 {
+		return motion(buf.Left())
+	}
+	orig := e.replaced[len(e.replaced)-1]
+	e.replaced = e.replaced[:len(e.replaced)-1]
+	if err := buf.AcceptChange(buffer.Backspace{}); err != nil {
+		return err
+	}
+	if orig < 0 {
+		return nil
+	}
+	if err := buf.AcceptChange(buffer.Insert{Contents: [][]rune{{orig}}}); err != nil {
+		return err
+	}
+	buf.Left()
+	return nil
+}
+
+// a new line in replace mode does not type over anything
+type ReplaceNewLine struct{}
+
+func (a ReplaceNewLine) String() string { return "replace new line" }
+func (a ReplaceNewLine) Apply(e *Editor) error {
+	e.replaced = nil
+	return EnterNewLine{}.Apply(e)
+}
+
 type EnterNewLine struct{}
 
 func (a EnterNewLine) String() string { return "new line (enter)" }
@@ -155,16 +339,193 @@ func (a Delete) Apply(e *Editor) error {
 	return e.BM.Current.Buf.AcceptChange(c)
 }
 
-type DeleteLine struct{}
+type DeleteLine struct{ count int }
 
-func (a DeleteLine) String() string { return "DeleteLine" }
+func (a DeleteLine) String() string             { return fmt.Sprintf("DeleteLine (%d)", a.count) }
+func (a DeleteLine) WithCount(count int) Action { return DeleteLine{count: count} }
 func (a DeleteLine) Apply(e *Editor) error {
-	c := buffer.DeleteLine{}
-	return e.BM.Current.Buf.StartAndAcceptChange(c, buffer.Event_Delete)
+	buf := e.BM.Current.Buf
+	reg := Register{Linewise: true}
+	for range max(a.count, 1) {
+		if len(buf.Rows) == 0 {
+			break
+		}
+		reg.Rows = append(reg.Rows, append([]rune(nil), buf.Rows[buf.Y()]...))
+		// like vim, the last line is emptied rather than deleted, so there is always a line
+		if len(buf.Rows) == 1 {
+			if err := buf.StartAndAcceptChange(buffer.SetRow{Y: 0, Contents: []rune{}}, buffer.Event_Delete); err != nil {
+				return err
+			}
+			buf.SetCursor(0, 0)
+			break
+		}
+		c := buffer.DeleteLine{}
+		if err := buf.StartAndAcceptChange(c, buffer.Event_Delete); err != nil {
+			return err
+		}
+		buf.SetCursor(0, buf.Y())
+	}
+	buf.Commit()
+	return e.regs.Set(e.takeRegister(), reg)
 }
 
+// registers
+
+// use a register for the next yank, delete or put
+type SelectRegister struct{ r rune }
+
+func (a SelectRegister) String() string        { return fmt.Sprintf("select register %s", string(a.r)) }
+func (a SelectRegister) Apply(e *Editor) error { e.register = a.r; return nil }
+
+type YankLine struct{ count int }
+
+func (a YankLine) String() string             { return fmt.Sprintf("yank line (%d)", a.count) }
+func (a YankLine) WithCount(count int) Action { return YankLine{count: count} }
+func (a YankLine) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	reg := Register{Linewise: true}
+	for y := buf.Y(); y < len(buf.Rows) && y < buf.Y()+max(a.count, 1); y++ {
+		reg.Rows = append(reg.Rows, append([]rune(nil), buf.Rows[y]...))
+	}
+	return e.regs.Set(e.takeRegister(), reg)
+}
+
+// put the contents of a register after (or before) the cursor
+type Put struct {
+	before bool
+	count  int
+}
+
+func (a Put) String() string             { return fmt.Sprintf("put (before: %v, %d)", a.before, a.count) }
+func (a Put) WithCount(count int) Action { return Put{before: a.before, count: count} }
+func (a Put) Apply(e *Editor) error {
+	reg, ok := e.regs.Get(e.takeRegister())
+	if !ok || len(reg.Rows) == 0 {
+		return nil
+	}
+	rows := [][]rune{}
+	for range max(a.count, 1) {
+		rows = append(rows, reg.Rows...)
+	}
+	buf := e.BM.Current.Buf
+	x, y := buf.X(), buf.Y()
+	var c buffer.InsertAt
+	switch {
+	case reg.Linewise && a.before:
+		c = buffer.InsertAt{Cur: buffer.Cursor{X: 0, Y: y}, Contents: append(rows, []rune{})}
+	case reg.Linewise:
+		c = buffer.InsertAt{Cur: buffer.Cursor{X: len(buf.Rows[y]), Y: y}, Contents: append([][]rune{{}}, rows...)}
+		y++
+	default:
+		// charwise contents are put as if typed, repeats are joined on the same line
+		joined := [][]rune{}
+		for range max(a.count, 1) {
+			if len(joined) == 0 {
+				joined = append(joined, reg.Rows...)
+				continue
+			}
+			joined[len(joined)-1] = append(append([]rune(nil), joined[len(joined)-1]...), reg.Rows[0]...)
+			joined = append(joined, reg.Rows[1:]...)
+		}
+		if !a.before && len(buf.Rows[y]) > 0 {
+			x++
+		}
+		c = buffer.InsertAt{Cur: buffer.Cursor{X: x, Y: y}, Contents: joined}
+	}
+	if err := buf.StartAndAcceptChange(c, buffer.Event_Insert); err != nil {
+		return err
+	}
+	buf.Commit()
+	if reg.Linewise {
+		buf.SetCursor(0, y)
+		return nil
+	}
+	buf.Left()
+	return nil
+}
+
+// macros
+
+type StartRecording struct{ r rune }
+
+func (a StartRecording) String() string { return fmt.Sprintf("start recording %s", string(a.r)) }
+func (a StartRecording) Apply(e *Editor) error {
+	e.macro.start(a.r)
+	return nil
+}
+
+type StopRecording struct{}
+
+func (a StopRecording) String() string { return "stop recording" }
+func (a StopRecording) Apply(e *Editor) error {
+	reg, contents := e.macro.stop()
+	return e.regs.Set(reg, contents)
+}
+
+type PlayMacro struct {
+	r     rune
+	count int
+}
+
+func (a PlayMacro) String() string             { return fmt.Sprintf("play macro %s (%d)", string(a.r), a.count) }
+func (a PlayMacro) WithCount(count int) Action { return PlayMacro{r: a.r, count: count} }
+func (a PlayMacro) Apply(e *Editor) error      { return e.playMacro(a.r, a.count) }
+
+// repeat the last change
+type RepeatChange struct{ count int }
+
+func (a RepeatChange) String() string             { return fmt.Sprintf("repeat change (%d)", a.count) }
+func (a RepeatChange) WithCount(count int) Action { return RepeatChange{count: count} }
+func (a RepeatChange) Apply(e *Editor) error      { return e.replay(e.rec.replay(a.count)) }
+
 // command stuff
 type InsertCommandChar struct{ c rune }
 
-func (a InsertCommandChar) String() string        { return fmt.Sprintf("insert command char %s", string(a.c)) }
-func (a InsertCommandChar) Apply(e *Editor) error { return nil }
+func (a InsertCommandChar) String() string { return fmt.Sprintf("insert command char %s", string(a.c)) }
+func (a InsertCommandChar) Apply(e *Editor) error {
+	e.cmdline = append(e.cmdline, a.c)
+	return nil
+}
+
+// backspace on an empty command line leaves command mode
+type CommandBackspace struct{}
+
+func (a CommandBackspace) String() string { return "command backspace" }
+func (a CommandBackspace) Apply(e *Editor) error {
+	if len(e.cmdline) == 0 {
+		return SwitchMode{m: mode.Normal}.Apply(e)
+	}
+	e.cmdline = e.cmdline[:len(e.cmdline)-1]
+	return nil
+}
+
+// run the command line
+//
+// a failed command shows its error as a message instead of exiting
+type ExecuteCommand struct{}
+
+func (a ExecuteCommand) String() string { return "execute command" }
+func (a ExecuteCommand) Apply(e *Editor) error {
+	line := string(e.cmdline)
+	if err := (SwitchMode{m: mode.Normal}).Apply(e); err != nil {
+		return err
+	}
+	err := e.Execute(line)
+	if err != nil && !errors.Is(err, ErrExit) {
+		e.message = err.Error()
+		return nil
+	}
+	return err
+}
+
+type InsertCommandText struct{ text string }
+
+func (a InsertCommandText) String() string { return fmt.Sprintf("insert command text %q", a.text) }
+func (a InsertCommandText) Apply(e *Editor) error {
+	for _, r := range a.text {
+		if err := (InsertCommandChar{c: r}).Apply(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
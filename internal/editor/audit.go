@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/jcocozza/jte/internal/audit"
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+// a character found by :unicodeaudit
+type finding struct {
+	x, y int
+	r    rune
+}
+
+// the list of what :unicodeaudit found, shown in its own pane
+type auditList struct {
+	buf  *buffer.Buffer
+	pane *Pane
+	// the pane that was audited, and the buffer it showed
+	//
+	// the panes are kept rather than their nodes, which a split makes internal nodes
+	source  *Pane
+	audited *buffer.Buffer
+	// a finding for each row of buf
+	findings []finding
+}
+
+// marks the rows with findings
+var auditSign = buffer.Sign{Source: "unicodeaudit", Text: "!", Priority: 10}
+
+// every hidden or confusable character in buf, in order
+func auditBuffer(buf *buffer.Buffer) []finding {
+	var found []finding
+	for y, row := range buf.Rows {
+		for x := range row {
+			if audit.At(row, x) != audit.None {
+				found = append(found, finding{x: x, y: y, r: row[x]})
+			}
+		}
+	}
+	return found
+}
+
+// list the hidden and confusable characters of the current buffer in a pane below it
+//
+// enter on a row of the list jumps to the character
+func unicodeAudit(e *Editor, r lineRange, args string) error {
+	if e.Active == nil {
+		return fmt.Errorf("no pane to audit")
+	}
+	buf := e.BM.Current.Buf
+	found := auditBuffer(buf)
+	buf.Signs.Clear(auditSign.Source)
+	for _, f := range found {
+		buf.Signs.Place(f.y, auditSign)
+	}
+	if len(found) == 0 {
+		e.message = "no hidden or confusable characters"
+		return nil
+	}
+	rows := make([]buffer.BufRow, len(found))
+	for i, f := range found {
+		rows[i] = buffer.BufRow(fmt.Sprintf("%d:%d: %s", f.y+1, f.x+1, audit.Describe(f.r)))
+	}
+	list := buffer.NewBuffer("[unicode audit] "+buf.Name, "", true, rows, e.logger)
+	e.BM.Add(list)
+
+	// a list that is still open is reused
+	e.unzoom()
+	if e.audit != nil && e.showing(e.audit.pane, e.audit.buf) != nil {
+		e.audit.pane.Show(list)
+		e.deleteBuffer(e.audit.buf)
+	} else {
+		source := e.Active
+		source.SplitHorizontal()
+		e.audit = &auditList{pane: source.Second.Pane}
+		e.audit.pane.Show(list)
+		e.Focus(source.First)
+	}
+	e.audit.buf = list
+	e.audit.source = e.Active.Pane
+	e.audit.audited = buf
+	e.audit.findings = found
+	e.Focus(e.showing(e.audit.pane, list))
+	e.message = fmt.Sprintf("%d hidden or confusable characters", len(found))
+	if len(found) == 1 {
+		e.message = "1 hidden or confusable character"
+	}
+	return nil
+}
+
+// the leaf of the layout with pane p, when p shows buf, nil otherwise
+func (e *Editor) showing(p *Pane, buf *buffer.Buffer) *SplitNode {
+	n := e.Root.leafOf(p)
+	if n == nil || p.Buf != buf {
+		return nil
+	}
+	return n
+}
+
+// the keys of normal mode in the :unicodeaudit list
+var auditBindings = NormalBindings.with(map[keyboard.Key]*BindingNode{
+	keyboard.ENTER: {children: nil, Actions: []Action{JumpToFinding{}}},
+})
+
+// jump to the character on the cursor row of the :unicodeaudit list
+type JumpToFinding struct{}
+
+func (a JumpToFinding) String() string { return "jump to finding" }
+func (a JumpToFinding) Apply(e *Editor) error {
+	buf := e.BM.Current.Buf
+	if e.audit == nil || buf != e.audit.buf || buf.Y() >= len(e.audit.findings) {
+		return ErrFailed
+	}
+	e.unzoom()
+	source := e.showing(e.audit.source, e.audit.audited)
+	if source == nil {
+		return fmt.Errorf("the audited buffer is no longer shown")
+	}
+	f := e.audit.findings[buf.Y()]
+	e.Focus(source)
+	e.audit.audited.SetCursor(f.x, f.y)
+	e.message = audit.Describe(f.r)
+	return nil
+}
+
+// the code point of the hidden or confusable character under the cursor, "" if there is none
+func (e *Editor) CharacterInfo() string {
+	if e.BM.Current == nil {
+		return ""
+	}
+	buf := e.BM.Current.Buf
+	if buf.Y() >= len(buf.Rows) || buf.X() >= len(buf.Rows[buf.Y()]) {
+		return ""
+	}
+	row := buf.Rows[buf.Y()]
+	if audit.At(row, buf.X()) == audit.None {
+		return ""
+	}
+	return audit.Describe(row[buf.X()])
+}
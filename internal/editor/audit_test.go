@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func TestUnicodeAudit(t *testing.T) {
+	e := newTestEditor("ok", "if a\u202e {", "\u0430dmin")
+	source := e.BM.Current.Buf
+	if err := e.Execute("unicodeaudit"); err != nil {
+		t.Fatal(err)
+	}
+	list := e.BM.Current.Buf
+	if list == source {
+		t.Fatal("the list is not the current buffer")
+	}
+	want := []string{
+		"2:5: U+202E right-to-left override (bidi)",
+		"3:1: U+0430 Cyrillic, looks like a (confusable)",
+	}
+	if got := rowsOf(e); !slices.Equal(got, want) {
+		t.Errorf("list = %q, want %q", got, want)
+	}
+	// the rows with findings are marked in the gutter
+	if _, ok := source.Signs.At(0); ok || source.Signs.Len() != 2 {
+		t.Errorf("%d rows have signs, want rows 1 and 2", source.Signs.Len())
+	}
+
+	typeKeys(t, e, 'j', keyboard.ENTER)
+	if e.BM.Current.Buf != source {
+		t.Fatal("enter did not go back to the audited buffer")
+	}
+	if source.X() != 0 || source.Y() != 2 {
+		t.Errorf("cursor = %d, %d, want 0, 2", source.X(), source.Y())
+	}
+	if got, want := e.CharacterInfo(), "U+0430 Cyrillic, looks like a (confusable)"; got != want {
+		t.Errorf("character info = %q, want %q", got, want)
+	}
+
+	// the list is reused
+	if err := e.Execute("unicodeaudit"); err != nil {
+		t.Fatal(err)
+	}
+	panes := 0
+	e.Root.Leaves(func(*SplitNode) { panes++ })
+	if panes != 2 {
+		t.Errorf("%d panes after a second audit, want 2", panes)
+	}
+}
+
+func TestUnicodeAudit_Clean(t *testing.T) {
+	e := newTestEditor("all ascii", "and 世界")
+	if err := e.Execute("unicodeaudit"); err != nil {
+		t.Fatal(err)
+	}
+	if e.Root.Pane == nil {
+		t.Error("a list was opened with nothing in it")
+	}
+	if e.CharacterInfo() != "" {
+		t.Errorf("character info = %q, want none", e.CharacterInfo())
+	}
+}
+
+func TestUnicodeAudit_AfterSplits(t *testing.T) {
+	e := newTestEditor("ok", "аdmin")
+	source := e.BM.Current.Buf
+	if err := e.Execute("unicodeaudit"); err != nil {
+		t.Fatal(err)
+	}
+	list := e.BM.Current.Buf
+	e.highlights[list] = &Highlighting{}
+	// both the list and the audited pane are split after the audit
+	typeKeys(t, e, 's')
+	e.Focus(e.Root.First)
+	typeKeys(t, e, 'v')
+	e.Focus(e.Root.leafOf(e.audit.pane))
+
+	typeKeys(t, e, keyboard.ENTER)
+	if e.BM.Current.Buf != source || source.Y() != 1 {
+		t.Fatalf("enter did not jump to the finding")
+	}
+	// elsewhere enter just moves down
+	typeKeys(t, e, 'k', keyboard.ENTER)
+	if e.BM.Current.Buf != source || source.Y() != 1 {
+		t.Errorf("enter outside the list did not move down")
+	}
+
+	if err := e.Execute("unicodeaudit"); err != nil {
+		t.Fatal(err)
+	}
+	panes := 0
+	e.Root.Leaves(func(*SplitNode) { panes++ })
+	if panes != 4 {
+		t.Errorf("%d panes after a second audit, want the list to be reused", panes)
+	}
+	if _, ok := e.highlights[list]; ok {
+		t.Errorf("the highlighting of the old list is kept")
+	}
+}
@@ -2,6 +2,7 @@ package editor
 
 import (
 	"fmt"
+	"maps"
 
 	"github.com/jcocozza/jte/internal/keyboard"
 	"github.com/jcocozza/jte/internal/mode"
@@ -10,6 +11,9 @@ import (
 type BindingNode struct {
 	children map[keyboard.Key]*BindingNode
 	Actions  []Action
+	// when set, the next key is an argument to the binding (e.g. the register in "qa")
+	// and the actions are built from it
+	Arg func(k keyboard.Key) []Action
 }
 
 func (n *BindingNode) IsLeaf() bool {
@@ -23,6 +27,9 @@ func (n *BindingNode) HasPrefix(keys keyboard.OrderedKeyList) bool {
 	if len(keys) == 0 {
 		return true
 	}
+	if n.Arg != nil {
+		return len(keys) == 1
+	}
 	child, ok := n.children[keys[0]]
 	if !ok {
 		return false
@@ -30,6 +37,29 @@ func (n *BindingNode) HasPrefix(keys keyboard.OrderedKeyList) bool {
 	return child.HasPrefix(keys[1:])
 }
 
+// a copy of the node with children added, or replaced
+func (n *BindingNode) with(children map[keyboard.Key]*BindingNode) *BindingNode {
+	c := *n
+	c.children = maps.Clone(n.children)
+	maps.Copy(c.children, children)
+	return &c
+}
+
+// the node reached by keys takes the next key as its argument
+func (n *BindingNode) takesArg(keys keyboard.OrderedKeyList) bool {
+	for _, k := range keys {
+		if n.Arg != nil {
+			return false
+		}
+		child, ok := n.children[k]
+		if !ok {
+			return false
+		}
+		n = child
+	}
+	return n.Arg != nil
+}
+
 // forcing an error here because I will be lazy if I don't
 //
 // return the node that is the result of traversing the bindings
@@ -40,6 +70,13 @@ func (n *BindingNode) Lookup(keys keyboard.OrderedKeyList) (*BindingNode, error)
 		}
 		return nil, fmt.Errorf("invalid key sequence, no leaf node: %s", keys.Collapse())
 	}
+	// an invalid argument is still a complete sequence, it just does nothing
+	if n.Arg != nil {
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("invalid key sequence %s", keys.Collapse())
+		}
+		return &BindingNode{Actions: n.Arg(keys[0])}, nil
+	}
 	child, ok := n.children[keys[0]]
 	if !ok {
 		return nil, fmt.Errorf("invalid key sequence %s", keys.Collapse())
@@ -57,7 +94,7 @@ var InsertBindings = &BindingNode{
 		keyboard.BACKSPACE_2: {children: nil, Actions: []Action{Backspace{}}},
 		keyboard.DELETE:      {children: nil, Actions: []Action{Delete{}}},
 
-		keyboard.TAB: {children: nil, Actions: []Action{Insert{c: rune(keyboard.TAB)}}},
+		keyboard.TAB: {children: nil, Actions: []Action{Insert{c: '\t'}}},
 		keyboard.ENTER: {children: nil, Actions: []Action{EnterNewLine{}}},
 
 		keyboard.ARROW_UP:    {children: nil, Actions: []Action{CursorUp{}}},
@@ -67,11 +104,35 @@ var InsertBindings = &BindingNode{
 	},
 }
 
+var ReplaceBindings = &BindingNode{
+	Actions: nil,
+	children: map[keyboard.Key]*BindingNode{
+		keyboard.ESC:   {Actions: []Action{SwitchMode{m: mode.Normal}}},
+		keyboard.CtrlC: {children: nil, Actions: []Action{Exit{}}},
+
+		keyboard.BACKSPACE:   {children: nil, Actions: []Action{ReplaceBackspace{}}},
+		keyboard.BACKSPACE_2: {children: nil, Actions: []Action{ReplaceBackspace{}}},
+
+		keyboard.TAB:   {children: nil, Actions: []Action{ReplaceChar{c: '\t'}}},
+		keyboard.ENTER: {children: nil, Actions: []Action{ReplaceNewLine{}}},
+
+		keyboard.ARROW_UP:    {children: nil, Actions: []Action{CursorUp{}}},
+		keyboard.ARROW_DOWN:  {children: nil, Actions: []Action{CursorDown{}}},
+		keyboard.ARROW_LEFT:  {children: nil, Actions: []Action{CursorLeft{}}},
+		keyboard.ARROW_RIGHT: {children: nil, Actions: []Action{CursorRight{}}},
+	},
+}
+
 var NormalBindings = &BindingNode{
 	Actions: nil,
 	children: map[keyboard.Key]*BindingNode{
 		'i':            {children: nil, Actions: []Action{SwitchMode{m: mode.Insert}}},
+		'R':            {children: nil, Actions: []Action{SwitchMode{m: mode.Replace}}},
+		':':            {children: nil, Actions: []Action{SwitchMode{m: mode.Command}}},
 		keyboard.CtrlC: {children: nil, Actions: []Action{Exit{}}},
+		keyboard.CtrlZ: {children: nil, Actions: []Action{Suspend{}}},
+		keyboard.CtrlL: {children: nil, Actions: []Action{Redraw{}}},
+		keyboard.ENTER: {children: nil, Actions: []Action{CursorDown{}}},
 
 		'o': {children: nil, Actions: []Action{SwitchMode{m: mode.Insert}, NewLineBelow{}}},
 		'O': {children: nil, Actions: []Action{SwitchMode{m: mode.Insert}, NewLineAbove{}}},
@@ -81,13 +142,95 @@ var NormalBindings = &BindingNode{
 				'd': {children: nil, Actions: []Action{DeleteLine{}}},
 			},
 		},
+		'.': {children: nil, Actions: []Action{RepeatChange{}}},
+
+		'"': {Arg: func(k keyboard.Key) []Action {
+			if !validRegister(rune(k)) {
+				return nil
+			}
+			return []Action{SelectRegister{r: rune(k)}}
+		}},
+		'y': {Actions: nil,
+			children: map[keyboard.Key]*BindingNode{
+				'y': {children: nil, Actions: []Action{YankLine{}}},
+			},
+		},
+		'p': {children: nil, Actions: []Action{Put{}}},
+		'P': {children: nil, Actions: []Action{Put{before: true}}},
+
+		'q': {Arg: func(k keyboard.Key) []Action {
+			if !validRegister(rune(k)) || k == unnamedRegister {
+				return nil
+			}
+			return []Action{StartRecording{r: rune(k)}}
+		}},
+		'@': {Arg: func(k keyboard.Key) []Action {
+			if !validRegister(rune(k)) && k != '@' {
+				return nil
+			}
+			return []Action{PlayMacro{r: rune(k)}}
+		}},
+
 		's': {children: nil, Actions: []Action{SplitHorizontal{}}},
 		'v': {children: nil, Actions: []Action{SplitVertical{}}},
 
+		keyboard.CtrlW: windowBindings,
+
 		'k': {children: nil, Actions: []Action{CursorUp{}}},
 		'j': {children: nil, Actions: []Action{CursorDown{}}},
 		'h': {children: nil, Actions: []Action{CursorLeft{}}},
 		'l': {children: nil, Actions: []Action{CursorRight{}}},
+
+		'g': screenLineBindings,
+	},
+}
+
+// motions by screen line, they differ from the others when rows wrap
+var screenLineBindings = &BindingNode{
+	children: map[keyboard.Key]*BindingNode{
+		'j': {children: nil, Actions: []Action{ScreenLineDown{}}},
+		'k': {children: nil, Actions: []Action{ScreenLineUp{}}},
+		'0': {children: nil, Actions: []Action{ScreenLineStart{}}},
+		'$': {children: nil, Actions: []Action{ScreenLineEnd{}}},
+	},
+}
+
+// the commands on the layout of panes, after Ctrl-W
+var windowBindings = &BindingNode{
+	children: map[keyboard.Key]*BindingNode{
+		's': {children: nil, Actions: []Action{SplitHorizontal{}}},
+		'v': {children: nil, Actions: []Action{SplitVertical{}}},
+
+		'h':                  {children: nil, Actions: []Action{FocusPane{side: LeftOf}}},
+		'j':                  {children: nil, Actions: []Action{FocusPane{side: Below}}},
+		'k':                  {children: nil, Actions: []Action{FocusPane{side: Above}}},
+		'l':                  {children: nil, Actions: []Action{FocusPane{side: RightOf}}},
+		keyboard.ARROW_LEFT:  {children: nil, Actions: []Action{FocusPane{side: LeftOf}}},
+		keyboard.ARROW_DOWN:  {children: nil, Actions: []Action{FocusPane{side: Below}}},
+		keyboard.ARROW_UP:    {children: nil, Actions: []Action{FocusPane{side: Above}}},
+		keyboard.ARROW_RIGHT: {children: nil, Actions: []Action{FocusPane{side: RightOf}}},
+		'w':                  {children: nil, Actions: []Action{FocusNextPane{}}},
+		keyboard.CtrlW:       {children: nil, Actions: []Action{FocusNextPane{}}},
+		'W':                  {children: nil, Actions: []Action{FocusNextPane{back: true}}},
+
+		'c': {children: nil, Actions: []Action{ClosePane{}}},
+		'q': {children: nil, Actions: []Action{ClosePane{quit: true}}},
+		'o': {children: nil, Actions: []Action{OnlyPane{}}},
+
+		'+': {children: nil, Actions: []Action{GrowPane{dir: Horizontal, by: 1}}},
+		'-': {children: nil, Actions: []Action{GrowPane{dir: Horizontal, by: -1}}},
+		'>': {children: nil, Actions: []Action{GrowPane{dir: Vertical, by: 1}}},
+		'<': {children: nil, Actions: []Action{GrowPane{dir: Vertical, by: -1}}},
+		'=': {children: nil, Actions: []Action{EqualizePanes{}}},
+
+		'x': {children: nil, Actions: []Action{ExchangePane{}}},
+		'r': {children: nil, Actions: []Action{RotatePanes{}}},
+		'R': {children: nil, Actions: []Action{RotatePanes{back: true}}},
+		'H': {children: nil, Actions: []Action{MovePane{side: LeftOf}}},
+		'J': {children: nil, Actions: []Action{MovePane{side: Below}}},
+		'K': {children: nil, Actions: []Action{MovePane{side: Above}}},
+		'L': {children: nil, Actions: []Action{MovePane{side: RightOf}}},
+		'z': {children: nil, Actions: []Action{ToggleZoom{}}},
 	},
 }
 
@@ -96,5 +239,32 @@ var CommandBindings = &BindingNode{
 	children: map[keyboard.Key]*BindingNode{
 		keyboard.ESC:   {children: nil, Actions: []Action{SwitchMode{m: mode.Normal}}},
 		keyboard.CtrlC: {children: nil, Actions: []Action{Exit{}}},
+
+		keyboard.ENTER:       {children: nil, Actions: []Action{ExecuteCommand{}}},
+		keyboard.BACKSPACE:   {children: nil, Actions: []Action{CommandBackspace{}}},
+		keyboard.BACKSPACE_2: {children: nil, Actions: []Action{CommandBackspace{}}},
+	},
+}
+
+var VisualBindings = &BindingNode{
+	Actions: nil,
+	children: map[keyboard.Key]*BindingNode{
+		keyboard.ESC:   {children: nil, Actions: []Action{SwitchMode{m: mode.Normal}}},
+		keyboard.CtrlC: {children: nil, Actions: []Action{Exit{}}},
+
+		'"': NormalBindings.children['"'],
+		'd': {children: nil, Actions: []Action{DeleteSelection{}}},
+		'x': {children: nil, Actions: []Action{DeleteSelection{}}},
+		'y': {children: nil, Actions: []Action{YankSelection{}}},
+
+		'k':                  {children: nil, Actions: []Action{CursorUp{}}},
+		'j':                  {children: nil, Actions: []Action{CursorDown{}}},
+		'h':                  {children: nil, Actions: []Action{CursorLeft{}}},
+		'l':                  {children: nil, Actions: []Action{CursorRight{}}},
+		keyboard.ARROW_UP:    {children: nil, Actions: []Action{CursorUp{}}},
+		keyboard.ARROW_DOWN:  {children: nil, Actions: []Action{CursorDown{}}},
+		keyboard.ARROW_LEFT:  {children: nil, Actions: []Action{CursorLeft{}}},
+		keyboard.ARROW_RIGHT: {children: nil, Actions: []Action{CursorRight{}}},
+		'g':                  screenLineBindings,
 	},
 }
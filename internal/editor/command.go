@@ -0,0 +1,229 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+	"github.com/jcocozza/jte/internal/mode"
+)
+
+// an ex command, run from the command line (e.g. :set nomouse)
+type command struct {
+	run func(e *Editor, r lineRange, args string) error
+	// the command works on lines, without a range it works on the cursor line
+	ranged bool
+	// without a range, the command works on the whole buffer
+	all bool
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"q":          {run: quit},
+		"quit":       {run: quit},
+		"set":        {run: set},
+		"w":          {run: write},
+		"write":      {run: write},
+		"wq":         {run: writeQuit},
+		"x":          {run: writeQuit},
+		"xit":        {run: writeQuit},
+		"d":          {run: deleteLines, ranged: true},
+		"delete":     {run: deleteLines, ranged: true},
+		"s":          {run: substitute, ranged: true},
+		"substitute": {run: substitute, ranged: true},
+		"g":          {run: global, ranged: true, all: true},
+		"global":     {run: global, ranged: true, all: true},
+		"v":          {run: vglobal, ranged: true, all: true},
+		"vglobal":    {run: vglobal, ranged: true, all: true},
+
+		"colo":        {run: colorScheme},
+		"colorscheme": {run: colorScheme},
+
+		"unicodeaudit": {run: unicodeAudit},
+
+		"clo":   {run: closePane},
+		"close": {run: closePane},
+		"on":    {run: onlyPane},
+		"only":  {run: onlyPane},
+	}
+}
+
+var ErrNotWritten = errors.New("no write since last change")
+
+// exit the editor, unless a buffer has changes that have not been written; q! exits anyway
+func quit(e *Editor, r lineRange, args string) error {
+	if args != "!" {
+		if err := e.unwritten(); err != nil {
+			return err
+		}
+	}
+	return ErrExit
+}
+
+// an error for the first buffer with changes that have not been written, the current one first
+func (e *Editor) unwritten() error {
+	if e.BM.Current.Buf.Modified {
+		return fmt.Errorf("%w (add ! to override)", ErrNotWritten)
+	}
+	for _, b := range e.BM.ListAll() {
+		if b.Modified {
+			return fmt.Errorf("%w for buffer %q (add ! to override)", ErrNotWritten, b.BufName)
+		}
+	}
+	return nil
+}
+
+// close the active pane, see ClosePane
+func closePane(e *Editor, r lineRange, args string) error {
+	if e.Active == nil {
+		return fmt.Errorf("no pane to close")
+	}
+	return e.closePane(e.Active)
+}
+
+// close every pane but the active one, see OnlyPane
+func onlyPane(e *Editor, r lineRange, args string) error {
+	if e.Active != nil {
+		e.onlyPane()
+	}
+	return nil
+}
+
+// write the current buffer to its file, or to the file named in args
+//
+// a buffer without a file takes the name it is first written to
+func write(e *Editor, r lineRange, args string) error {
+	buf := e.BM.Current.Buf
+	path := buf.FilePath
+	var n int
+	var err error
+	switch {
+	case args == "" || args == buf.FilePath:
+		n, err = buf.Save()
+	case buf.FilePath == "":
+		buf.FilePath, buf.Name = args, args
+		path = args
+		n, err = buf.Save()
+	default:
+		path = args
+		n, err = fileutil.Save(args, buf.Bytes())
+	}
+	if err != nil {
+		return err
+	}
+	e.message = fmt.Sprintf("%q %dL, %dB written", path, len(buf.Rows), n)
+	return nil
+}
+
+// write the current buffer and exit
+//
+// in pipe mode the buffer goes to the pipe, and only to a file if it has one
+func writeQuit(e *Editor, r lineRange, args string) error {
+	buf := e.BM.Current.Buf
+	if e.Pipe == nil || args != "" || buf.FilePath != "" {
+		if err := write(e, r, args); err != nil {
+			return err
+		}
+	}
+	if e.Pipe != nil {
+		if _, err := e.Pipe.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return ErrExit
+}
+
+func set(e *Editor, r lineRange, args string) error {
+	options := e.Options.options()
+	if e.Active != nil && e.Active.Pane != nil {
+		options.merge(e.Active.Pane.options())
+	}
+	for _, arg := range setArgs(args) {
+		msg, err := options.set(arg)
+		if err != nil {
+			return err
+		}
+		if msg != "" {
+			e.message = msg
+		}
+	}
+	return nil
+}
+
+// run an ex command line (what is typed after :)
+//
+// a line can start with a range of lines (e.g. :1,5d or :%s/a/b/), a range on its own moves the cursor
+func (e *Editor) Execute(line string) error {
+	line = strings.TrimLeft(line, " \t:")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	buf := e.BM.Current.Buf
+	r, rest, err := e.parseRange(line)
+	if err != nil {
+		return err
+	}
+	rest = trimArgs(rest)
+	if rest == "" {
+		if r.given {
+			buf.SetCursor(0, r.end)
+		}
+		return nil
+	}
+	name, args := splitCommand(rest)
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("not an editor command: %s", line)
+	}
+	if !cmd.ranged && r.given {
+		return fmt.Errorf("no range allowed: %s", line)
+	}
+	if !r.given && cmd.all {
+		r = lineRange{start: 0, end: len(buf.Rows) - 1}
+	}
+	// an empty buffer has no cursor line
+	r.end = min(r.end, len(buf.Rows)-1)
+	return cmd.run(e, r, args)
+}
+
+// the name of a command is its letters, e.g. s in s/a/b/ or g in g!/a/d
+func splitCommand(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if i < 0 {
+		return s, ""
+	}
+	if i == 0 {
+		return s[:1], trimArgs(s[1:])
+	}
+	return s[:i], trimArgs(s[i:])
+}
+
+// s without the blanks around it, a blank at the end after a backslash is kept
+// (e.g. in :set showbreak=>\ )
+func trimArgs(s string) string {
+	s = strings.TrimLeft(s, " \t")
+	t := strings.TrimRight(s, " \t")
+	if strings.HasSuffix(t, "\\") && len(t) < len(s) {
+		return s[:len(t)+1]
+	}
+	return t
+}
+
+// the text of the command line while in command mode
+//
+// returns false when not in command mode
+func (e *Editor) CommandLine() (string, bool) {
+	if e.m.Current() != mode.Command {
+		return "", false
+	}
+	return ":" + string(e.cmdline), true
+}
+
+// a message for the user, shown at the bottom of the screen until the next key
+func (e *Editor) Message() string {
+	return e.message
+}
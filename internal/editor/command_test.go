@@ -0,0 +1,109 @@
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/gutter"
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func TestQuit(t *testing.T) {
+	e := newTestEditor("a", "b")
+	if err := e.Execute("q"); !errors.Is(err, ErrExit) {
+		t.Errorf("q without changes: error = %v, want ErrExit", err)
+	}
+	typeKeys(t, e, 'd', 'd')
+	if err := e.Execute("q"); !errors.Is(err, ErrNotWritten) {
+		t.Errorf("q with changes: error = %v, want ErrNotWritten", err)
+	}
+	if err := e.Execute("q!"); !errors.Is(err, ErrExit) {
+		t.Errorf("q!: error = %v, want ErrExit", err)
+	}
+	// Ctrl-W q on the last pane is :q
+	typeKeys(t, e, keyboard.CtrlW, 'q')
+	if want := "no write since last change (add ! to override)"; e.Message() != want {
+		t.Errorf("Ctrl-W q with changes: message = %q, want %q", e.Message(), want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	e := newTestEditor("a", "b")
+	path := filepath.Join(t.TempDir(), "out")
+	if err := e.Execute("w " + path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a\nb\n" {
+		t.Errorf("file = %q, want %q", got, "a\nb\n")
+	}
+	if buf := e.BM.Current.Buf; buf.FilePath != path {
+		t.Errorf("file path = %q, want %q", buf.FilePath, path)
+	}
+}
+
+func TestWriteQuit_Pipe(t *testing.T) {
+	e := newTestEditor("a", "b")
+	var out bytes.Buffer
+	e.Pipe = &out
+	if err := e.Execute("wq"); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("pipe = %q, want %q", out.String(), "a\nb\n")
+	}
+	if buf := e.BM.Current.Buf; buf.FilePath != "" {
+		t.Errorf("file path = %q, want none", buf.FilePath)
+	}
+}
+
+func TestColorScheme(t *testing.T) {
+	e := newTestEditor("a")
+	if err := e.Execute("colorscheme solarized"); err != nil {
+		t.Fatal(err)
+	}
+	if e.Theme().Name != "solarized" {
+		t.Errorf("theme = %q, want solarized", e.Theme().Name)
+	}
+	if err := e.Execute("colo nope"); err == nil || e.Theme().Name != "solarized" {
+		t.Errorf("an unknown theme: error = %v, theme = %q", err, e.Theme().Name)
+	}
+	if err := e.Execute("colo"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "solarized (default solarized)"; e.Message() != want {
+		t.Errorf("message = %q, want %q", e.Message(), want)
+	}
+}
+
+func TestSet_PaneOptions(t *testing.T) {
+	e := newTestEditor("a")
+	e.Active.Pane.G = &gutter.Gutter{Number: true}
+	typeKeys(t, e, 'v')
+	if err := e.Execute("set rnu nomouse"); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Active.Pane.G.RelativeNumber || e.Options.Mouse {
+		t.Error("the options were not set")
+	}
+	// the other pane has a gutter of its own, with the settings from before the split
+	if other := e.Root.Second.Pane.G; other.RelativeNumber || !other.Number {
+		t.Errorf("the other pane's gutter is %+v", other)
+	}
+	if err := e.Execute("set relativenumber?"); err != nil || e.Message() != "relativenumber" {
+		t.Errorf("message = %q, error = %v", e.Message(), err)
+	}
+	// a blank is escaped with a backslash, also at the end of the line
+	if err := e.Execute(`set wrap showbreak=\ >\ `); err != nil || e.Active.Pane.WrapOptions.ShowBreak != " > " {
+		t.Errorf("showbreak = %q, error = %v", e.Active.Pane.WrapOptions.ShowBreak, err)
+	}
+	if err := e.Execute("set sbr?"); err != nil || e.Message() != "sbr= > " {
+		t.Errorf("message = %q, error = %v", e.Message(), err)
+	}
+}
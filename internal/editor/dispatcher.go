@@ -17,6 +17,12 @@ type Dispatcher struct {
 	logger         *slog.Logger
 	currKeys       keyboard.OrderedKeyList
 	repeatModifier int
+	// the last key was a digit of the count
+	counting bool
+
+	// the keys and count of the last dispatch
+	lastKeys  keyboard.OrderedKeyList
+	lastCount int
 }
 
 func NewDispatcher(l *slog.Logger) *Dispatcher {
@@ -47,9 +53,31 @@ func (d *Dispatcher) processInsert(k keyboard.Key, n *BindingNode) (bool, []Acti
 		}
 		return true, actionNode.Actions
 	}
+	if !k.IsUnicode() {
+		return true, nil
+	}
 	return true, []Action{Insert{rune(d.currKeys[0])}}
 }
 
+// in replace mode, like insert mode, but text types over what is there
+//
+// return true to flush, false to continue
+func (d *Dispatcher) processReplace(k keyboard.Key, n *BindingNode) (bool, []Action) {
+	d.accept(k)
+	possiblyValid := n.HasPrefix(d.currKeys)
+	if possiblyValid {
+		actionNode, err := n.Lookup(d.currKeys)
+		if err != nil {
+			return false, nil
+		}
+		return true, actionNode.Actions
+	}
+	if !k.IsUnicode() {
+		return true, nil
+	}
+	return true, []Action{ReplaceChar{rune(d.currKeys[0])}}
+}
+
 // in command mode:
 // 1. check for valid sequence (e.g. <enter>, <esc>, etc)
 // 2. if valid dispatch command
@@ -66,6 +94,9 @@ func (d *Dispatcher) processCommand(k keyboard.Key, n *BindingNode) (bool, []Act
 		}
 		return true, actionNode.Actions
 	}
+	if !k.IsUnicode() {
+		return true, nil
+	}
 	return true, []Action{InsertCommandChar{rune(d.currKeys[0])}}
 }
 
@@ -75,8 +106,11 @@ func (d *Dispatcher) processCommand(k keyboard.Key, n *BindingNode) (bool, []Act
 //
 // return true to flush, false to continue
 func (d *Dispatcher) processNormal(k keyboard.Key, n *BindingNode) (bool, []Action) {
-	if k.IsDigit() {
+	// a count comes before the keys or after an operator (as in d2d). it does not start with 0,
+	// so that 0 can be a key (as in g0), and a binding that takes an argument gets the digit
+	if k.IsDigit() && (k != '0' || d.counting) && !n.takesArg(d.currKeys) {
 		digit := int(k - '0')
+		d.counting = true
 		if d.repeatModifier == 0 {
 			d.repeatModifier = digit
 			return false, nil
@@ -84,6 +118,7 @@ func (d *Dispatcher) processNormal(k keyboard.Key, n *BindingNode) (bool, []Acti
 		d.repeatModifier = (d.repeatModifier * 10) + digit
 		return false, nil
 	}
+	d.counting = false
 	d.accept(k)
 	possiblyValid := n.HasPrefix(d.currKeys)
 	if possiblyValid {
@@ -91,6 +126,11 @@ func (d *Dispatcher) processNormal(k keyboard.Key, n *BindingNode) (bool, []Acti
 		if err != nil {
 			return false, nil
 		}
+		if len(actionNode.Actions) == 1 {
+			if ca, ok := actionNode.Actions[0].(CountedAction); ok {
+				return true, []Action{ca.WithCount(d.repeatModifier)}
+			}
+		}
 		if d.repeatModifier == 0 || d.repeatModifier == 1 {
 			return true, actionNode.Actions
 		}
@@ -103,6 +143,18 @@ func (d *Dispatcher) processNormal(k keyboard.Key, n *BindingNode) (bool, []Acti
 	return true, nil // since nothing matches, we just want to flush right away
 }
 
+// true if keys have been accepted that are not yet dispatched (including a count)
+func (d *Dispatcher) Pending() bool {
+	return len(d.currKeys) > 0 || d.repeatModifier > 0
+}
+
+// drop any keys that have not been dispatched
+func (d *Dispatcher) Reset() {
+	d.repeatModifier = 0
+	d.counting = false
+	d.currKeys = keyboard.OrderedKeyList{}
+}
+
 var ErrNoDispatch = errors.New("no dispatch")
 
 // this is run one time per event loop
@@ -115,12 +167,14 @@ func (d *Dispatcher) ProcessKeypress(k keyboard.Key, m mode.Mode, n *BindingNode
 	var flush bool
 	var actions []Action
 	switch m {
-	case mode.Normal:
+	case mode.Normal, mode.Visual:
 		flush, actions = d.processNormal(k, n)
 	case mode.Command:
 		flush, actions = d.processCommand(k, n)
 	case mode.Insert:
 		flush, actions = d.processInsert(k, n)
+	case mode.Replace:
+		flush, actions = d.processReplace(k, n)
 	default:
 		panic(fmt.Sprintf("invalid mode on dispatch: %s", m))
 	}
@@ -129,6 +183,8 @@ func (d *Dispatcher) ProcessKeypress(k keyboard.Key, m mode.Mode, n *BindingNode
 		return nil, ErrNoDispatch
 	}
 
+	d.lastKeys = d.currKeys
+	d.lastCount = d.repeatModifier
 	d.repeatModifier = 0
 	d.currKeys = keyboard.OrderedKeyList{}
 	return actions, nil
@@ -1,11 +1,14 @@
 package editor
 
 import (
+	"errors"
+	"io"
 	"log/slog"
 
 	"github.com/jcocozza/jte/internal/buffer"
 	"github.com/jcocozza/jte/internal/keyboard"
 	"github.com/jcocozza/jte/internal/mode"
+	"github.com/jcocozza/jte/internal/theme"
 )
 
 type Editor struct {
@@ -14,8 +17,46 @@ type Editor struct {
 	d  *Dispatcher
 	BM *buffer.BufferManager
 
+	rec   *changeRecorder
+	macro *macroRecorder
+	regs  *Registers
+	// the register selected for the next yank, delete or put
+	register rune
+
+	Options Options
+	// when stdout is a pipe, :wq writes the buffer here (e.g. jte - | sort)
+	Pipe    io.Writer
+	cmdline []rune
+	message string
+	// the last pattern used by an ex command, for an empty pattern (e.g. :g/a/s//b/)
+	pattern string
+	// set while :g runs, it cannot be nested
+	global bool
+	// what the last :unicodeaudit found
+	audit *auditList
+	// the syntax highlighting of each buffer that has any
+	highlights map[*buffer.Buffer]*Highlighting
+	// the colors, set with :colorscheme
+	theme *theme.Theme
+	// the other end of the selection in visual mode
+	visual buffer.Cursor
+	mouse  mouseState
+	// the characters typed over in replace mode, so backspace can put them back
+	//
+	// -1 for characters that were added at the end of a line instead
+	replaced []rune
+	// set by Suspend, the terminal is handed back to the shell once the events that are waiting have been handled
+	suspend bool
+	// set by Redraw, the next frame repaints the whole screen
+	redraw bool
+	events *events
+	// what Run draws on
+	screen Screen
+
 	Root   *SplitNode
 	Active *SplitNode
+	// the whole layout while the active pane is zoomed, Root is then just the pane
+	zoomed *SplitNode
 
 	logger *slog.Logger
 }
@@ -26,22 +67,114 @@ func NewEditor(l *slog.Logger) *Editor {
 		m:      mode.NewStateMachine(l),
 		d:      NewDispatcher(l),
 		BM:     buffer.NewBufferManager(l),
-		Root:   nil,
-		Active: nil,
+		rec:    &changeRecorder{},
+		macro:  &macroRecorder{},
+		regs:   NewRegisters(),
+		events: newEvents(),
+
+		highlights: make(map[*buffer.Buffer]*Highlighting),
+		theme:      defaultTheme(),
+
+		Options: DefaultOptions(),
+		Root:    nil,
+		Active:  nil,
 
 		logger: l.WithGroup("editor"),
 	}
 }
 
+// the keyboard the editor reads its input from
+func (e *Editor) Keyboard() *keyboard.Keyboard {
+	return e.kb
+}
+
 func (e *Editor) Mode() string {
 	return string(e.m.Current())
 }
 
+// wait for a key press and handle it
 func (e *Editor) HandleKeypress() error {
-	k, err := e.kb.GetKeypress()
+	ev, err := e.kb.GetEvent()
 	if err != nil {
 		return err
 	}
+	return e.handleEvent(ev)
+}
+
+func (e *Editor) handleEvent(ev keyboard.Event) error {
+	// nothing is bound to letting go of a key
+	if ev.Release {
+		return nil
+	}
+	e.message = ""
+	if ev.IsPaste() {
+		return e.handlePaste(ev.Paste)
+	}
+	if ev.Mouse != nil {
+		return e.handleMouse(ev.Mouse)
+	}
+	return e.handleKey(ev.Key)
+}
+
+// handle text pasted by the user
+//
+// the paste is inserted as a single change, but recorded as if it had been typed
+func (e *Editor) handlePaste(text string) error {
+	state := e.m.Current()
+	keys := keyboard.PasteKeys(text)
+	if state == mode.Normal {
+		keys = append(append(keyboard.OrderedKeyList{'i'}, keys...), keyboard.ESC)
+	}
+	if e.macro.recording() {
+		e.macro.keys = append(e.macro.keys, keys...)
+	}
+	e.rec.paste(keys, state)
+	// a paste in the middle of a key sequence cancels it
+	e.d.Reset()
+	var a Action = Paste{text: text}
+	if state == mode.Command {
+		a = InsertCommandText{text: text}
+	}
+	e.logger.Debug("applying action", slog.String("action", a.String()))
+	return a.Apply(e)
+}
+
+// handle a key typed by the user
+func (e *Editor) handleKey(k keyboard.Key) error {
+	if e.macro.recording() {
+		// a q on its own ends the recording and is not part of it
+		if k == 'q' && e.m.Current() == mode.Normal && !e.d.Pending() {
+			return StopRecording{}.Apply(e)
+		}
+		e.macro.keys.Append(k)
+	}
+	err := e.processKey(k)
+	if errors.Is(err, ErrFailed) {
+		e.logger.Debug("action failed", slog.String("error", err.Error()))
+		return nil
+	}
+	return err
+}
+
+// the register selected for the next yank, delete or put
+//
+// resets the selection back to the unnamed register
+func (e *Editor) takeRegister() rune {
+	r := e.register
+	e.register = 0
+	if r == 0 {
+		return unnamedRegister
+	}
+	return r
+}
+
+// remove buf from the buffers, and forget what the editor kept about it
+func (e *Editor) deleteBuffer(buf *buffer.Buffer) {
+	e.BM.Delete(buf.ID())
+	delete(e.highlights, buf)
+}
+
+func (e *Editor) processKey(k keyboard.Key) error {
 	var n *BindingNode
 	state := e.m.Current()
 	switch state {
@@ -51,9 +184,17 @@ func (e *Editor) HandleKeypress() error {
 		n = InsertBindings
 	case mode.Normal:
 		n = NormalBindings
+		if e.audit != nil && e.BM.Current.Buf == e.audit.buf {
+			n = auditBindings
+		}
+	case mode.Visual:
+		n = VisualBindings
+	case mode.Replace:
+		n = ReplaceBindings
 	default:
 		panic("invalid state")
 	}
+	e.rec.key(k, state)
 	actions, err := e.d.ProcessKeypress(k, state, n)
 	// no dispatch, nothing to do
 	if err != nil {
@@ -66,5 +207,30 @@ func (e *Editor) HandleKeypress() error {
 			return err
 		}
 	}
+	e.rec.dispatched(e.d.lastKeys, e.d.lastCount, actions, state, e.m.Current())
+	return nil
+}
+
+// replay the keys of a change
+//
+// keys that are replayed are not recorded as a new change
+func (e *Editor) replay(keys keyboard.OrderedKeyList) error {
+	if e.rec.replaying {
+		return nil
+	}
+	e.rec.replaying = true
+	defer func() { e.rec.replaying = false }()
+	return e.feed(keys)
+}
+
+// feed keys through the dispatcher as if they were typed
+//
+// stops at the first key that fails
+func (e *Editor) feed(keys keyboard.OrderedKeyList) error {
+	for _, k := range keys {
+		if err := e.processKey(k); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,320 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/buffer"
+)
+
+// the lines an ex command works on, 0 indexed and inclusive
+type lineRange struct {
+	start, end int
+	// the command line had a range
+	given bool
+}
+
+// parse the range at the start of an ex command line
+//
+// an address is a line number, . (the cursor line), $ (the last line) or /pattern/ (the next line that matches),
+// followed by any number of +N or -N. % is the whole buffer.
+// returns the rest of the line
+func (e *Editor) parseRange(s string) (lineRange, string, error) {
+	buf := e.BM.Current.Buf
+	if rest, ok := strings.CutPrefix(s, "%"); ok {
+		return lineRange{start: 0, end: len(buf.Rows) - 1, given: true}, rest, nil
+	}
+	start, s, ok, err := e.parseAddress(s)
+	if err != nil || !ok {
+		return lineRange{start: buf.Y(), end: buf.Y()}, s, err
+	}
+	r := lineRange{start: start, end: start, given: true}
+	if rest, ok := strings.CutPrefix(s, ","); ok {
+		var end int
+		end, s, ok, err = e.parseAddress(rest)
+		if err != nil {
+			return r, s, err
+		}
+		if !ok {
+			return r, s, fmt.Errorf("missing address after ,")
+		}
+		r.end = end
+	}
+	if r.start > r.end {
+		return r, s, fmt.Errorf("backwards range: %d,%d", r.start+1, r.end+1)
+	}
+	if r.start < 0 || r.end >= len(buf.Rows) {
+		return r, s, fmt.Errorf("invalid range: %d,%d", r.start+1, r.end+1)
+	}
+	return r, s, nil
+}
+
+// returns false when s does not start with an address
+func (e *Editor) parseAddress(s string) (int, string, bool, error) {
+	buf := e.BM.Current.Buf
+	var line int
+	switch {
+	case s == "":
+		return 0, s, false, nil
+	case s[0] == '.':
+		line, s = buf.Y(), s[1:]
+	case s[0] == '$':
+		line, s = len(buf.Rows)-1, s[1:]
+	case s[0] >= '0' && s[0] <= '9':
+		n, rest := leadingNumber(s)
+		line, s = n-1, rest
+	case s[0] == '/':
+		pat, rest := cutPattern(s[1:], '/')
+		re, err := e.compile(pat)
+		if err != nil {
+			return 0, rest, false, err
+		}
+		line = -1
+		for i := range len(buf.Rows) {
+			y := (buf.Y() + 1 + i) % len(buf.Rows)
+			if re.MatchString(string(buf.Rows[y])) {
+				line = y
+				break
+			}
+		}
+		if line < 0 {
+			return 0, rest, false, fmt.Errorf("pattern not found: %s", pat)
+		}
+		s = rest
+	case s[0] == '+' || s[0] == '-':
+		// an offset on its own is from the cursor line
+		line = buf.Y()
+	default:
+		return 0, s, false, nil
+	}
+	for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		n, rest := leadingNumber(s[1:])
+		if rest == s[1:] {
+			n = 1
+		}
+		line, s = line+sign*n, rest
+	}
+	return line, s, true, nil
+}
+
+// the number at the start of s, and the rest of s
+func leadingNumber(s string) (int, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, _ := strconv.Atoi(s[:i])
+	return n, s[i:]
+}
+
+// the text up to the unescaped delim, and the text after it
+//
+// \delim is unescaped, other escapes are kept for the regular expression
+func cutPattern(s string, delim byte) (string, string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			b.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			b.WriteString(s[i : i+2])
+			i++
+		case s[i] == delim:
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
+
+// patterns are go regular expressions, an empty pattern is the last one used
+func (e *Editor) compile(pat string) (*regexp.Regexp, error) {
+	if pat == "" {
+		if e.pattern == "" {
+			return nil, errors.New("no previous pattern")
+		}
+		pat = e.pattern
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, fmt.Errorf("bad pattern: %w", err)
+	}
+	e.pattern = pat
+	return re, nil
+}
+
+// :[range]d
+func deleteLines(e *Editor, r lineRange, args string) error {
+	if args != "" {
+		return fmt.Errorf("trailing characters: %s", args)
+	}
+	buf := e.BM.Current.Buf
+	buf.SetCursor(0, r.start)
+	return DeleteLine{count: r.end - r.start + 1}.Apply(e)
+}
+
+// :[range]s/pattern/replacement/[flags]
+//
+// in the replacement & is the whole match and \1 to \9 are groups.
+// flags: g replaces every match in a line, not just the first; i ignores case; e does not fail when nothing matches
+func substitute(e *Editor, r lineRange, args string) error {
+	if args == "" {
+		return errors.New("substitute needs a pattern")
+	}
+	delim := args[0]
+	pat, rest := cutPattern(args[1:], delim)
+	rep, flags := cutPattern(rest, delim)
+	all, ignoreCase, ignoreMissing := false, false, false
+	for _, f := range flags {
+		switch f {
+		case 'g':
+			all = true
+		case 'i':
+			ignoreCase = true
+		case 'e':
+			ignoreMissing = true
+		default:
+			return fmt.Errorf("bad flag %q", f)
+		}
+	}
+	re, err := e.compile(pat)
+	if err != nil {
+		return err
+	}
+	if ignoreCase {
+		re = regexp.MustCompile("(?i)" + re.String())
+	}
+	tmpl := replacement(rep)
+	limit := 1
+	if all {
+		limit = -1
+	}
+
+	buf := e.BM.Current.Buf
+	last := -1
+	for y := r.start; y <= r.end; y++ {
+		row := string(buf.Rows[y])
+		matches := re.FindAllStringSubmatchIndex(row, limit)
+		if matches == nil {
+			continue
+		}
+		var out []byte
+		prev := 0
+		for _, m := range matches {
+			out = append(out, row[prev:m[0]]...)
+			out = re.ExpandString(out, tmpl, row, m)
+			prev = m[1]
+		}
+		out = append(out, row[prev:]...)
+		if err := buf.StartAndAcceptChange(buffer.SetRow{Y: y, Contents: []rune(string(out))}, buffer.Event_Replace); err != nil {
+			return err
+		}
+		last = y
+	}
+	buf.Commit()
+	if last < 0 {
+		if ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("pattern not found: %s", e.pattern)
+	}
+	buf.SetCursor(0, last)
+	return nil
+}
+
+// a vim replacement (& and \1) as a go template ($0 and ${1})
+func replacement(rep string) string {
+	var b strings.Builder
+	for i := 0; i < len(rep); i++ {
+		c := rep[i]
+		switch {
+		case c == '\\' && i+1 < len(rep):
+			i++
+			switch n := rep[i]; {
+			case n >= '0' && n <= '9':
+				fmt.Fprintf(&b, "${%c}", n)
+			case n == 't':
+				b.WriteByte('\t')
+			case n == '$':
+				b.WriteString("$$")
+			default:
+				b.WriteByte(n)
+			}
+		case c == '&':
+			b.WriteString("${0}")
+		case c == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// :[range]g/pattern/cmd runs cmd on every line that matches
+func global(e *Editor, r lineRange, args string) error {
+	if rest, ok := strings.CutPrefix(args, "!"); ok {
+		return runGlobal(e, r, rest, false)
+	}
+	return runGlobal(e, r, args, true)
+}
+
+// :[range]v/pattern/cmd runs cmd on every line that does not match
+func vglobal(e *Editor, r lineRange, args string) error {
+	return runGlobal(e, r, args, false)
+}
+
+// the lines are found first, then cmd runs with the cursor on each of them in turn.
+// lines that cmd adds or removes move the lines that are still to come, so cmd should
+// only change the line it runs on (e.g. d, s)
+func runGlobal(e *Editor, r lineRange, args string, match bool) error {
+	if e.global {
+		return errors.New("cannot nest :g")
+	}
+	if args == "" {
+		return errors.New("global needs a pattern")
+	}
+	pat, cmd := cutPattern(args[1:], args[0])
+	re, err := e.compile(pat)
+	if err != nil {
+		return err
+	}
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" {
+		// there is no :p to default to, so there is nothing to do
+		return errors.New("global needs a command")
+	}
+
+	buf := e.BM.Current.Buf
+	var lines []int
+	for y := r.start; y <= r.end; y++ {
+		if re.MatchString(string(buf.Rows[y])) == match {
+			lines = append(lines, y)
+		}
+	}
+	e.global = true
+	defer func() { e.global = false }()
+	shift := 0
+	for _, y := range lines {
+		y += shift
+		if y < 0 || y >= len(buf.Rows) {
+			break
+		}
+		before := len(buf.Rows)
+		buf.SetCursor(0, y)
+		if err := e.Execute(cmd); err != nil {
+			return fmt.Errorf("line %d: %w", y+1, err)
+		}
+		shift += len(buf.Rows) - before
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExecute_Ex(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		cmds    []string
+		want    []string
+		wantY   int
+		wantErr bool
+	}{
+		{
+			name:  "delete a line",
+			rows:  []string{"a", "b", "c"},
+			cmds:  []string{"2d"},
+			want:  []string{"a", "c"},
+			wantY: 1,
+		},
+		{
+			name:  "delete a range",
+			rows:  []string{"a", "b", "c", "d"},
+			cmds:  []string{"2,$-1d"},
+			want:  []string{"a", "d"},
+			wantY: 1,
+		},
+		{
+			name:  "delete from a pattern",
+			rows:  []string{"a", "b", "c", "d"},
+			cmds:  []string{"/c/,$d"},
+			want:  []string{"a", "b"},
+			wantY: 1,
+		},
+		{
+			name:  "go to a line",
+			rows:  []string{"a", "b", "c"},
+			cmds:  []string{"3", ".-1"},
+			want:  []string{"a", "b", "c"},
+			wantY: 1,
+		},
+		{
+			name:  "substitute on the cursor line",
+			rows:  []string{"aa", "aa"},
+			cmds:  []string{"s/a/b/"},
+			want:  []string{"ba", "aa"},
+			wantY: 0,
+		},
+		{
+			name:  "substitute everywhere",
+			rows:  []string{"foo(x)", "y", "foo(z)"},
+			cmds:  []string{`%s/foo\((\w)\)/bar(\1, &)/g`},
+			want:  []string{"bar(x, foo(x))", "y", "bar(z, foo(z))"},
+			wantY: 2,
+		},
+		{
+			name: "substitute with another delimiter and flags",
+			rows: []string{"A/a/A"},
+			cmds: []string{`s#a#\$#gi`},
+			want: []string{"$/$/$"},
+		},
+		{
+			name:    "substitute without a match",
+			rows:    []string{"a"},
+			cmds:    []string{"s/x/y/"},
+			want:    []string{"a"},
+			wantErr: true,
+		},
+		{
+			name: "substitute without a match, quietly",
+			rows: []string{"a"},
+			cmds: []string{"s/x/y/e"},
+			want: []string{"a"},
+		},
+		{
+			name:  "global delete",
+			rows:  []string{"// TODO a", "b", "// TODO c", "// TODO d", "e"},
+			cmds:  []string{"g/TODO/d"},
+			want:  []string{"b", "e"},
+			wantY: 1,
+		},
+		{
+			name:  "global substitute with the same pattern",
+			rows:  []string{"x1", "y", "x2"},
+			cmds:  []string{"g/x/s//z/"},
+			want:  []string{"z1", "y", "z2"},
+			wantY: 2,
+		},
+		{
+			name:  "inverse global",
+			rows:  []string{"keep 1", "drop", "keep 2"},
+			cmds:  []string{"v/keep/d"},
+			want:  []string{"keep 1", "keep 2"},
+			wantY: 1,
+		},
+		{
+			name: "global on a range",
+			rows: []string{"x", "x", "x"},
+			cmds: []string{"2,3g!/y/d"},
+			want: []string{"x"},
+		},
+		{
+			name:    "bad range",
+			rows:    []string{"a"},
+			cmds:    []string{"1,5d"},
+			want:    []string{"a"},
+			wantErr: true,
+		},
+		{
+			name:    "range for a command without one",
+			rows:    []string{"a"},
+			cmds:    []string{"1set mouse"},
+			want:    []string{"a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			var err error
+			for _, c := range tt.cmds {
+				if err = e.Execute(c); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := rowsOf(e); !slices.Equal(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if got := e.BM.Current.Buf.Y(); !tt.wantErr && got != tt.wantY {
+				t.Errorf("cursor line = %d, want %d", got, tt.wantY)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"context"
+
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/syntax"
+)
+
+// the most lines a frame highlights itself, the rest is left to the background
+const highlightBudget = 500
+
+// the syntax highlighting of a buffer, kept up to date as it is edited
+type Highlighting struct {
+	e     *Editor
+	buf   *buffer.Buffer
+	cache *syntax.Cache
+	// a job is highlighting in the background, the cache is left alone until it is done
+	running bool
+}
+
+// the syntax highlighting of buf, nil when its file type has none
+func (e *Editor) Highlighting(buf *buffer.Buffer) *Highlighting {
+	hl := syntax.For(buf.FileType)
+	if hl == nil {
+		return nil
+	}
+	h, ok := e.highlights[buf]
+	if !ok {
+		h = &Highlighting{e: e, buf: buf, cache: syntax.NewCache(hl)}
+		buf.OnEdit(h.cache.Edit)
+		e.highlights[buf] = h
+	} else if h.cache.Highlighter() != hl {
+		h.cache.Reset(hl)
+	}
+	return h
+}
+
+// bring the lines through to up to date
+//
+// the few lines after an edit are highlighted right away. when more is out of date (e.g. after
+// jumping to the end of a big file) it is highlighted in the background, and until then the lines
+// look the way they did
+func (h *Highlighting) Update(to int) {
+	if h.running {
+		return
+	}
+	row := func(y int) []rune { return h.buf.Rows[y] }
+	if h.cache.Update(len(h.buf.Rows), row, to, highlightBudget) {
+		return
+	}
+	job := h.cache.Job(h.e.events.ctx, len(h.buf.Rows), row, to)
+	h.running = true
+	RunJob(h.e, func(ctx context.Context) (*syntax.Job, error) {
+		return job, job.Run()
+	}, func(e *Editor, job *syntax.Job, err error) error {
+		h.running = false
+		// an edit stops the job, the next frame goes on from the first line it changed
+		h.cache.Finish(job)
+		return nil
+	})
+}
+
+// the spans of row y, as of the last update
+func (h *Highlighting) Spans(y int) []syntax.Span {
+	return h.cache.Spans(y)
+}
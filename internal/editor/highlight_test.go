@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+	"github.com/jcocozza/jte/internal/keyboard"
+	"github.com/jcocozza/jte/internal/syntax"
+)
+
+// the types of row y's spans
+func spanTypes(h *Highlighting, y int) []fileutil.SyntaxType {
+	var types []fileutil.SyntaxType
+	for _, sp := range h.Spans(y) {
+		types = append(types, sp.Type)
+	}
+	return types
+}
+
+func TestHighlighting_Edits(t *testing.T) {
+	e := newTestEditor("x", "y := 1", "z")
+	buf := e.BM.Current.Buf
+	buf.FileType = "go"
+	h := e.Highlighting(buf)
+	h.Update(2)
+	if got := spanTypes(h, 1); len(got) != 1 || got[0] != fileutil.NUMBER {
+		t.Fatalf("row 1 = %v, want a number", got)
+	}
+
+	// opening a comment on the first row makes a comment of the rows below
+	typeKeys(t, e, 'i', '/', '*', keyboard.ESC)
+	h.Update(2)
+	for y := range 3 {
+		if got := spanTypes(h, y); len(got) != 1 || got[0] != fileutil.COMMENT {
+			t.Errorf("row %d = %v, want a comment", y, got)
+		}
+	}
+	// and deleting its row takes them back
+	typeKeys(t, e, 'd', 'd')
+	h.Update(1)
+	if got := spanTypes(h, 0); len(got) != 1 || got[0] != fileutil.NUMBER {
+		t.Errorf("row 0 = %v after dd, want a number", got)
+	}
+}
+
+func TestHighlighting_Background(t *testing.T) {
+	rows := make([]string, 2*highlightBudget)
+	for i := range rows {
+		rows[i] = "x := 1"
+	}
+	rows[0] = "/*"
+	e := newTestEditor(rows...)
+	buf := e.BM.Current.Buf
+	buf.FileType = "go"
+	h := e.Highlighting(buf)
+	last := len(rows) - 1
+	h.Update(last)
+	if !h.running {
+		t.Fatal("no job for more lines than a frame highlights")
+	}
+	if h.Spans(last) != nil {
+		t.Error("the last row was highlighted before the job finished")
+	}
+	// the loop takes the job's result, then the frame after it has the last row
+	s := &jobScreen{h: h, last: last}
+	if err := e.loop(s); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	want := syntax.Span{Start: 0, End: len(rows[last]), Type: fileutil.COMMENT}
+	if got := h.Spans(last); len(got) != 1 || got[0] != want {
+		t.Errorf("last row = %+v, want %+v", got, want)
+	}
+}
+
+// renders until the job is done
+type jobScreen struct {
+	h    *Highlighting
+	last int
+}
+
+func (s *jobScreen) Render(e *Editor) {
+	s.h.Update(s.last)
+	if !s.h.running {
+		e.Post(exit)
+	}
+}
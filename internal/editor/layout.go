@@ -8,14 +8,90 @@ import (
 type SplitDirection int
 
 const (
-	Horizontal SplitDirection = iota // side-by-side
-	Vertical                         // top and bottom
+	Horizontal SplitDirection = iota // top and bottom
+	Vertical                         // side-by-side
 )
 
+// a rectangle on the screen, in cells
+type Rect struct {
+	X, Y       int
+	Rows, Cols int
+}
+
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Cols && y >= r.Y && y < r.Y+r.Rows
+}
+
 type Pane struct {
-	G   *gutter.Gutter
-	Buf *buffer.Buffer
+	G      *gutter.Gutter
+	Buf    *buffer.Buffer
 	Active bool // if the cursor is on this node
+	// the pane's own cursor into Buf, the buffer moves it while the pane is active
+	View *buffer.View
+
+	// the first buffer row and display column that are shown
+	RowOffset int
+	ColOffset int
+
+	// split rows that are too long across screen lines, rather than scroll sideways
+	Wrap        bool
+	WrapOptions buffer.WrapOptions
+	// when wrapping, the screen lines of the row at RowOffset that are scrolled out of view
+	SegOffset int
+}
+
+// the pane's view of its buffer, a pane without one gets one at the buffer's cursor
+func (p *Pane) view() *buffer.View {
+	if p.View == nil {
+		p.View = p.Buf.NewView(nil)
+	}
+	return p.View
+}
+
+// where the pane's cursor is
+func (p *Pane) Cursor() buffer.Cursor {
+	return p.view().Cursor
+}
+
+// show buf in the pane, from the top
+func (p *Pane) Show(buf *buffer.Buffer) {
+	if p.View != nil {
+		p.Buf.CloseView(p.View)
+	}
+	p.Buf, p.View = buf, nil
+	p.RowOffset, p.ColOffset, p.SegOffset = 0, 0, 0
+}
+
+// a pane like p, with a view of its own at p's cursor
+func (p *Pane) clone() *Pane {
+	c := &Pane{
+		Buf:         p.Buf,
+		View:        p.Buf.NewView(p.view()),
+		RowOffset:   p.RowOffset,
+		ColOffset:   p.ColOffset,
+		Wrap:        p.Wrap,
+		WrapOptions: p.WrapOptions,
+		SegOffset:   p.SegOffset,
+	}
+	// the new pane starts with the gutter settings of the old one
+	if p.G != nil {
+		g := *p.G
+		c.G = &g
+	}
+	return c
+}
+
+// the pane's gutter, a pane without one gets an empty one
+func (p *Pane) Gutter() *gutter.Gutter {
+	if p.G == nil {
+		p.G = &gutter.Gutter{}
+	}
+	return p.G
+}
+
+// the columns the gutter takes up in a pane cols wide, the text gets at least one
+func (p *Pane) GutterWidth(cols int) int {
+	return max(min(p.Gutter().Width(len(p.Buf.Rows), &p.Buf.Signs), cols-1), 0)
 }
 
 // Pane is nil if this is just a split tracking node
@@ -28,57 +104,419 @@ type SplitNode struct {
 	FirstRatio float64 // Ratio of the split
 
 	Pane *Pane // nil for internal
+
+	// where the node is on the screen, set by Resize
+	Rect Rect
 }
 
-func (s *SplitNode) GetUp() {}
-func (s *SplitNode) GetDown() {}
-func (s *SplitNode) GetLeft() {}
-func (s *SplitNode) GetRight() {}
+// a side of a pane
+type Side int
+
+const (
+	Above Side = iota
+	Below
+	LeftOf
+	RightOf
+)
 
-func (s *SplitNode) Resize(newWidth, newHeight int) {
-	if s.Dir == Horizontal {
-		// Resize horizontally based on ratio
-		firstWidth := int(float64(newWidth) * s.FirstRatio)
-		secondWidth := newWidth - firstWidth
-		s.First.Resize(firstWidth, newHeight)
-		s.Second.Resize(secondWidth, newHeight)
+// the leaf next to leaf n on side of it, nil when n is at the edge of the layout
+//
+// where several leaves are along that side, it is the one beside at (a column for Above and
+// Below, a row for LeftOf and RightOf), or else the one nearest to it
+func (s *SplitNode) Neighbor(n *SplitNode, side Side, at int) *SplitNode {
+	var best *SplitNode
+	bestDist := 0
+	s.Leaves(func(l *SplitNode) {
+		var touches bool
+		// the extent of l and n along the side
+		var lo, hi, nlo, nhi int
+		switch side {
+		case Above, Below:
+			touches = l.Rect.Y+l.Rect.Rows == n.Rect.Y
+			if side == Below {
+				touches = l.Rect.Y == n.Rect.Y+n.Rect.Rows
+			}
+			lo, hi, nlo, nhi = l.Rect.X, l.Rect.X+l.Rect.Cols, n.Rect.X, n.Rect.X+n.Rect.Cols
+		case LeftOf, RightOf:
+			// side-by-side leaves have a border between them
+			touches = l.Rect.X+l.Rect.Cols+1 == n.Rect.X
+			if side == RightOf {
+				touches = l.Rect.X == n.Rect.X+n.Rect.Cols+1
+			}
+			lo, hi, nlo, nhi = l.Rect.Y, l.Rect.Y+l.Rect.Rows, n.Rect.Y, n.Rect.Y+n.Rect.Rows
+		}
+		if l == n || !touches || lo >= nhi || hi <= nlo {
+			return
+		}
+		dist := 0
+		if at < lo {
+			dist = lo - at
+		} else if at >= hi {
+			dist = at - hi + 1
+		}
+		if best == nil || dist < bestDist {
+			best, bestDist = l, dist
+		}
+	})
+	return best
+}
+
+// the smallest a pane can be: a row of text above the status line, and a column
+const (
+	MinPaneRows = 2
+	MinPaneCols = 1
+)
+
+// the smallest size the node can have without squashing any of its panes
+func (s *SplitNode) minSize() (rows int, cols int) {
+	if s.Pane != nil {
+		return MinPaneRows, MinPaneCols
+	}
+	fr, fc := s.First.minSize()
+	sr, sc := s.Second.minSize()
+	if s.Dir == Vertical {
+		return max(fr, sr), fc + 1 + sc
+	}
+	return fr + sr, max(fc, sc)
+}
+
+// the size of the first part when total is split by ratio
+//
+// both parts get at least their minimum size. when there is not enough room for that,
+// the space is shared in proportion to the minimums, so that everything shrinks together
+func splitSize(total int, ratio float64, firstMin int, secondMin int) int {
+	total = max(total, 0)
+	if total < firstMin+secondMin {
+		return total * firstMin / (firstMin + secondMin)
+	}
+	// the epsilon keeps a ratio set from a size (e.g. 29/100) from rounding down to the size below
+	size := int(float64(total)*ratio + 1e-9)
+	return min(max(size, firstMin), total-secondMin)
+}
+
+// lay out the node, and all of its children, in r
+//
+// side-by-side nodes are separated by a one column border
+func (s *SplitNode) Resize(r Rect) {
+	s.Rect = r
+	if s.Pane != nil {
+		return
+	}
+	fr, fc := s.First.minSize()
+	sr, sc := s.Second.minSize()
+	if s.Dir == Vertical {
+		// the first part includes the border
+		firstW := splitSize(r.Cols, s.FirstRatio, fc+1, sc)
+		s.First.Resize(Rect{X: r.X, Y: r.Y, Rows: r.Rows, Cols: max(firstW-1, 0)})
+		s.Second.Resize(Rect{X: r.X + firstW, Y: r.Y, Rows: r.Rows, Cols: max(r.Cols-firstW, 0)})
+	} else {
+		firstH := splitSize(r.Rows, s.FirstRatio, fr, sr)
+		s.First.Resize(Rect{X: r.X, Y: r.Y, Rows: firstH, Cols: r.Cols})
+		s.Second.Resize(Rect{X: r.X, Y: r.Y + firstH, Rows: max(r.Rows-firstH, 0), Cols: r.Cols})
+	}
+}
+
+// the number of columns of a leaf available for text, right of the gutter
+func (s *SplitNode) TextCols() int {
+	return max(s.Rect.Cols-s.Pane.GutterWidth(s.Rect.Cols), 0)
+}
+
+// the number of rows of a leaf available for text (the last row is the status line)
+func (s *SplitNode) TextRows() int {
+	return max(s.Rect.Rows-1, 0)
+}
+
+// the column of the border between side-by-side nodes
+func (s *SplitNode) BorderCol() int {
+	return s.First.Rect.X + s.First.Rect.Cols
+}
+
+// the leaf that is at x, y on the screen
+//
+// returns nil if there is none (e.g. x, y is on a border)
+func (s *SplitNode) Leaf(x, y int) *SplitNode {
+	if !s.Rect.Contains(x, y) {
+		return nil
+	}
+	if s.Pane != nil {
+		return s
+	}
+	if n := s.First.Leaf(x, y); n != nil {
+		return n
+	}
+	return s.Second.Leaf(x, y)
+}
+
+// the split whose border is at x, y on the screen
+//
+// for side-by-side splits this is the column between them,
+// for top and bottom splits it is the status line of the top node
+func (s *SplitNode) Border(x, y int) *SplitNode {
+	if s.Pane != nil || !s.Rect.Contains(x, y) {
+		return nil
+	}
+	if s.Dir == Vertical && x == s.BorderCol() {
+		return s
+	}
+	if s.Dir == Horizontal && y == s.First.Rect.Y+s.First.Rect.Rows-1 {
+		return s
+	}
+	if n := s.First.Border(x, y); n != nil {
+		return n
+	}
+	return s.Second.Border(x, y)
+}
+
+// move the border of the split to x, y on the screen
+//
+// both sides keep their minimum size
+func (s *SplitNode) MoveBorder(x, y int) {
+	if s.Pane != nil {
+		return
+	}
+	if s.Dir == Vertical {
+		s.setFirstSize(x - s.Rect.X + 1)
 	} else {
-		// Resize vertically based on ratio
-		firstHeight := int(float64(newHeight) * s.FirstRatio)
-		secondHeight := newHeight - firstHeight
-		s.First.Resize(newWidth, firstHeight)
-		s.Second.Resize(newWidth, secondHeight)
+		s.setFirstSize(y - s.Rect.Y + 1)
 	}
 }
 
+// the size of the first part of the split, in rows, or in columns with the border
+func (s *SplitNode) firstSize() int {
+	if s.Dir == Vertical {
+		return s.First.Rect.Cols + 1
+	}
+	return s.First.Rect.Rows
+}
+
+// give the first part of the split size rows, or columns with the border
+//
+// both sides keep their minimum size
+func (s *SplitNode) setFirstSize(size int) {
+	fr, fc := s.First.minSize()
+	sr, sc := s.Second.minSize()
+	var total, firstMin, secondMin int
+	if s.Dir == Vertical {
+		total, firstMin, secondMin = s.Rect.Cols, fc+1, sc
+	} else {
+		total, firstMin, secondMin = s.Rect.Rows, fr, sr
+	}
+	if total < firstMin+secondMin {
+		return
+	}
+	size = min(max(size, firstMin), total-secondMin)
+	s.FirstRatio = float64(size) / float64(total)
+	s.Resize(s.Rect)
+}
+
+// call fn for each leaf, in order
+func (s *SplitNode) Leaves(fn func(n *SplitNode)) {
+	if s == nil {
+		return
+	}
+	if s.Pane != nil {
+		fn(s)
+		return
+	}
+	s.First.Leaves(fn)
+	s.Second.Leaves(fn)
+}
+
+// the leaf with pane p, nil when p is not in the layout
+func (s *SplitNode) leafOf(p *Pane) *SplitNode {
+	var n *SplitNode
+	s.Leaves(func(l *SplitNode) {
+		if l.Pane == p {
+			n = l
+		}
+	})
+	return n
+}
+
+// the first and the last leaf of the node
+func (s *SplitNode) firstLeaf() *SplitNode {
+	for s.Pane == nil {
+		s = s.First
+	}
+	return s
+}
+
+func (s *SplitNode) lastLeaf() *SplitNode {
+	for s.Pane == nil {
+		s = s.Second
+	}
+	return s
+}
+
+// the node that n is a child of, nil for the root or a node that is not in the layout
+func (s *SplitNode) parent(n *SplitNode) *SplitNode {
+	if s == nil || s.Pane != nil {
+		return nil
+	}
+	if s.First == n || s.Second == n {
+		return s
+	}
+	if p := s.First.parent(n); p != nil {
+		return p
+	}
+	return s.Second.parent(n)
+}
+
+// take leaf n out of the layout, the other child of its parent takes the parent's place
+//
+// returns the root of what is left, which is s unless s was the parent, and the leaf of
+// the other child that was next to n. the root cannot be taken out, s is returned with no leaf
+func (s *SplitNode) Remove(n *SplitNode) (root *SplitNode, next *SplitNode) {
+	p := s.parent(n)
+	if p == nil {
+		return s, nil
+	}
+	other, next := p.Second, p.Second.firstLeaf()
+	if p.Second == n {
+		other, next = p.First, p.First.lastLeaf()
+	}
+	other.Resize(p.Rect)
+	gp := s.parent(p)
+	switch {
+	case gp == nil:
+		return other, next
+	case gp.First == p:
+		gp.First = other
+	default:
+		gp.Second = other
+	}
+	return s, next
+}
+
+// the number of panes next to each other in dir across the node
+func (s *SplitNode) across(dir SplitDirection) int {
+	if s.Pane != nil {
+		return 1
+	}
+	f, sc := s.First.across(dir), s.Second.across(dir)
+	if s.Dir == dir {
+		return f + sc
+	}
+	return max(f, sc)
+}
+
+// give the panes next to each other the same size
+func (s *SplitNode) Equalize() {
+	if s.Pane != nil {
+		return
+	}
+	f, sc := s.First.across(s.Dir), s.Second.across(s.Dir)
+	s.FirstRatio = float64(f) / float64(f+sc)
+	s.First.Equalize()
+	s.Second.Equalize()
+	s.Resize(s.Rect)
+}
+
+// make leaf n by rows (columns for Vertical) bigger, smaller when by is negative
+//
+// the space comes from the pane next to it in the nearest split in dir
+func (s *SplitNode) Grow(n *SplitNode, dir SplitDirection, by int) {
+	child := n
+	for p := s.parent(n); p != nil; child, p = p, s.parent(p) {
+		if p.Dir != dir {
+			continue
+		}
+		if p.First == child {
+			p.setFirstSize(p.firstSize() + by)
+		} else {
+			p.setFirstSize(p.firstSize() - by)
+		}
+		return
+	}
+}
+
+// the nodes in a line with leaf n, in order: the children of the splits in the direction of
+// n's parent that the parent is part of
+func (s *SplitNode) row(n *SplitNode) []*SplitNode {
+	p := s.parent(n)
+	if p == nil {
+		return []*SplitNode{n}
+	}
+	for gp := s.parent(p); gp != nil && gp.Dir == p.Dir; gp = s.parent(p) {
+		p = gp
+	}
+	var row []*SplitNode
+	var add func(c *SplitNode)
+	add = func(c *SplitNode) {
+		if c.Pane == nil && c.Dir == p.Dir {
+			add(c.First)
+			add(c.Second)
+			return
+		}
+		row = append(row, c)
+	}
+	add(p)
+	return row
+}
+
+// move leaf n to an edge of the layout, side of everything else and along the whole of it
+//
+// returns the root of the new layout
+func (s *SplitNode) MoveToEdge(n *SplitNode, side Side) *SplitNode {
+	if s == n {
+		return s
+	}
+	r := s.Rect
+	rest, _ := s.Remove(n)
+	dir := Horizontal
+	if side == LeftOf || side == RightOf {
+		dir = Vertical
+	}
+	// n gets as much room as the panes already next to each other there
+	others := rest.across(dir)
+	root := &SplitNode{Dir: dir, First: n, Second: rest, FirstRatio: 1 / float64(others+1)}
+	if side == Below || side == RightOf {
+		root.First, root.Second = rest, n
+		root.FirstRatio = float64(others) / float64(others+1)
+	}
+	root.Resize(r)
+	return root
+}
+
+// split the pane in two, side by side, the new pane is on the right
+//
 // return the new "active" split node
 func (s *SplitNode) SplitVertical() *SplitNode {
-	if s.Pane == nil {
-		panic("cannot split an internal node")
-	}
-	ogPane := s.Pane
-	clonedPane := *ogPane
-	clonedPane.Active = false
-	s.Dir = Vertical
-	s.First = &SplitNode{Pane: ogPane}
-	s.Second = &SplitNode{Pane: &clonedPane}
-	s.FirstRatio = .5
-	s.Pane = nil
-	return s.First
+	return s.split(Vertical)
 }
 
+// split the pane in two, one above the other, the new pane is below
+//
 // return the new "active" split node
 func (s *SplitNode) SplitHorizontal() *SplitNode {
+	return s.split(Horizontal)
+}
+
+// the pane stays in the first node, the second gets a copy of it
+func (s *SplitNode) split(dir SplitDirection) *SplitNode {
 	if s.Pane == nil {
 		panic("cannot split an internal node")
 	}
-	ogPane := s.Pane
-	clonedPane := *ogPane
-	clonedPane.Active = false
-	s.Dir = Horizontal
-	s.First = &SplitNode{Pane: ogPane}
-	s.Second = &SplitNode{Pane: &clonedPane}
+	s.Dir = dir
+	s.First = &SplitNode{Pane: s.Pane}
+	s.Second = &SplitNode{Pane: s.Pane.clone()}
 	s.FirstRatio = .5
 	s.Pane = nil
 	return s.First
 }
+
+// a layout of panes next to each other in dir, all the same size
+func NewLayout(dir SplitDirection, panes ...*Pane) *SplitNode {
+	if len(panes) == 0 {
+		return nil
+	}
+	if len(panes) == 1 {
+		return &SplitNode{Pane: panes[0]}
+	}
+	return &SplitNode{
+		Dir:        dir,
+		First:      &SplitNode{Pane: panes[0]},
+		Second:     NewLayout(dir, panes[1:]...),
+		FirstRatio: 1 / float64(len(panes)),
+	}
+}
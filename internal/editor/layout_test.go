@@ -0,0 +1,192 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func TestSplitNode_Resize(t *testing.T) {
+	tests := []struct {
+		name  string
+		dir   SplitDirection
+		ratio float64
+		size  Rect
+		first Rect
+		secnd Rect
+	}{
+		{
+			name: "side by side", dir: Vertical, ratio: .5, size: Rect{Rows: 10, Cols: 41},
+			first: Rect{Rows: 10, Cols: 19}, secnd: Rect{X: 20, Rows: 10, Cols: 21},
+		},
+		{
+			name: "top and bottom", dir: Horizontal, ratio: .5, size: Rect{Rows: 11, Cols: 10},
+			first: Rect{Rows: 5, Cols: 10}, secnd: Rect{Y: 5, Rows: 6, Cols: 10},
+		},
+		{
+			name: "ratio keeps the minimum size", dir: Horizontal, ratio: .05, size: Rect{Rows: 20, Cols: 10},
+			first: Rect{Rows: MinPaneRows, Cols: 10}, secnd: Rect{Y: MinPaneRows, Rows: 20 - MinPaneRows, Cols: 10},
+		},
+		{
+			name: "ratio from a size", dir: Horizontal, ratio: 29.0 / 100, size: Rect{Rows: 100, Cols: 10},
+			first: Rect{Rows: 29, Cols: 10}, secnd: Rect{Y: 29, Rows: 71, Cols: 10},
+		},
+		{
+			name: "too small", dir: Vertical, ratio: .9, size: Rect{Rows: 1, Cols: 2},
+			first: Rect{Rows: 1, Cols: 0}, secnd: Rect{X: 1, Rows: 1, Cols: 1},
+		},
+		{
+			name: "nothing", dir: Horizontal, ratio: .5, size: Rect{},
+			first: Rect{}, secnd: Rect{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &SplitNode{Dir: tt.dir, FirstRatio: tt.ratio, First: &SplitNode{Pane: &Pane{}}, Second: &SplitNode{Pane: &Pane{}}}
+			n.Resize(tt.size)
+			if n.First.Rect != tt.first {
+				t.Errorf("first = %+v, want %+v", n.First.Rect, tt.first)
+			}
+			if n.Second.Rect != tt.secnd {
+				t.Errorf("second = %+v, want %+v", n.Second.Rect, tt.secnd)
+			}
+		})
+	}
+}
+
+func TestSplitNode_ResizeNested(t *testing.T) {
+	// the second half is split again, so it needs more room than the first
+	inner := &SplitNode{Dir: Horizontal, FirstRatio: .5, First: &SplitNode{Pane: &Pane{}}, Second: &SplitNode{Pane: &Pane{}}}
+	n := &SplitNode{Dir: Horizontal, FirstRatio: .9, First: &SplitNode{Pane: &Pane{}}, Second: inner}
+	n.Resize(Rect{Rows: 10, Cols: 10})
+	n.Leaves(func(l *SplitNode) {
+		if l.Rect.Rows < MinPaneRows {
+			t.Errorf("pane at %d has %d rows", l.Rect.Y, l.Rect.Rows)
+		}
+	})
+}
+
+func TestSplit_PanesHaveTheirOwnViews(t *testing.T) {
+	e := newTestEditor("zero", "one", "two", "three")
+	buf := e.BM.Current.Buf
+	typeKeys(t, e, 'j', 'j', 'l', 'v')
+	left, right := e.Root.First, e.Root.Second
+	if left.Pane == right.Pane || left.Pane.View == right.Pane.View {
+		t.Fatal("the panes share their state")
+	}
+	if right.Pane.Cursor() != left.Pane.Cursor() {
+		t.Errorf("the new pane is at %+v, want %+v", right.Pane.Cursor(), left.Pane.Cursor())
+	}
+
+	// moving in one pane leaves the other where it was
+	typeKeys(t, e, 'k', 'k')
+	left.Pane.RowOffset = 1
+	e.Focus(right)
+	if buf.X() != 1 || buf.Y() != 2 {
+		t.Errorf("cursor = %d,%d in the other pane, want 1,2", buf.X(), buf.Y())
+	}
+	if right.Pane.RowOffset != 0 {
+		t.Errorf("the other pane scrolled to %d", right.Pane.RowOffset)
+	}
+
+	// an edit in one pane keeps the other on its text
+	typeKeys(t, e, 'O', 'n', 'e', 'w', keyboard.ESC)
+	e.Focus(left)
+	if buf.X() != 1 || buf.Y() != 0 {
+		t.Errorf("cursor = %d,%d after a row was inserted below, want 1,0", buf.X(), buf.Y())
+	}
+	typeKeys(t, e, 'j', 'j', 'j', 'd', 'd')
+	e.Focus(right)
+	if got := string(buf.Rows[buf.Y()]); got != "new" {
+		t.Errorf("the other pane's cursor is on %q, want the row it was on", got)
+	}
+}
+
+// a pane on the left, and two on the right one above the other
+//
+//	+------+------+
+//	| left | top  |
+//	|      +------+
+//	|      | bot  |
+//	+------+------+
+func threePanes() (root, left, top, bottom *SplitNode) {
+	left, top, bottom = &SplitNode{Pane: &Pane{}}, &SplitNode{Pane: &Pane{}}, &SplitNode{Pane: &Pane{}}
+	right := &SplitNode{Dir: Horizontal, FirstRatio: .5, First: top, Second: bottom}
+	root = &SplitNode{Dir: Vertical, FirstRatio: .5, First: left, Second: right}
+	root.Resize(Rect{Rows: 20, Cols: 41})
+	return root, left, top, bottom
+}
+
+func TestSplitNode_Neighbor(t *testing.T) {
+	root, left, top, bottom := threePanes()
+	tests := []struct {
+		name string
+		from *SplitNode
+		side Side
+		at   int
+		want *SplitNode
+	}{
+		{name: "right, beside the top", from: left, side: RightOf, at: 3, want: top},
+		{name: "right, beside the bottom", from: left, side: RightOf, at: 15, want: bottom},
+		{name: "left", from: bottom, side: LeftOf, at: 15, want: left},
+		{name: "below", from: top, side: Below, at: 30, want: bottom},
+		{name: "above", from: bottom, side: Above, at: 30, want: top},
+		{name: "edge", from: left, side: LeftOf, at: 3, want: nil},
+		{name: "edge below", from: bottom, side: Below, at: 30, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := root.Neighbor(tt.from, tt.side, tt.at); got != tt.want {
+				t.Errorf("Neighbor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitNode_Remove(t *testing.T) {
+	root, left, top, bottom := threePanes()
+	got, next := root.Remove(top)
+	if got != root || next != bottom {
+		t.Fatalf("Remove() = %p, %p, want the root and the bottom pane", got, next)
+	}
+	if root.Second != bottom || bottom.Rect != (Rect{X: 20, Rows: 20, Cols: 21}) {
+		t.Errorf("the bottom pane did not take the place of its parent: %+v", bottom.Rect)
+	}
+	got, next = root.Remove(bottom)
+	if got != left || next != left {
+		t.Errorf("Remove() = %p, %p, want the left pane as the root", got, next)
+	}
+	if got, next = left.Remove(left); got != left || next != nil {
+		t.Errorf("the root was removed")
+	}
+}
+
+func TestSplitNode_GrowAndEqualize(t *testing.T) {
+	root, left, top, bottom := threePanes()
+	root.Grow(top, Horizontal, 3)
+	if top.Rect.Rows != 13 || bottom.Rect.Rows != 7 {
+		t.Errorf("rows = %d, %d after growing, want 13, 7", top.Rect.Rows, bottom.Rect.Rows)
+	}
+	root.Grow(top, Vertical, -5)
+	if left.Rect.Cols != 24 || top.Rect.Cols != 16 {
+		t.Errorf("cols = %d, %d after shrinking, want 24, 16", left.Rect.Cols, top.Rect.Cols)
+	}
+	root.Equalize()
+	if left.Rect.Cols != 19 || top.Rect.Rows != 10 {
+		t.Errorf("cols = %d, rows = %d after equalizing, want 19, 10", left.Rect.Cols, top.Rect.Rows)
+	}
+}
+
+func TestSplitNode_MoveToEdge(t *testing.T) {
+	root, left, top, bottom := threePanes()
+	root = root.MoveToEdge(top, Above)
+	if root.Dir != Horizontal || root.First != top {
+		t.Fatalf("the pane is not at the top")
+	}
+	if top.Rect != (Rect{Rows: 10, Cols: 41}) {
+		t.Errorf("top = %+v, want the whole width", top.Rect)
+	}
+	if root.Second.Dir != Vertical || root.Second.First != left || root.Second.Second != bottom {
+		t.Errorf("the other panes are not side by side below it")
+	}
+}
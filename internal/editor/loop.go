@@ -0,0 +1,314 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+// draws the editor, once all events that are waiting have been handled
+type Screen interface {
+	Render(e *Editor)
+}
+
+// a screen that can hand the terminal back to the shell for a while (Ctrl-Z)
+type Suspender interface {
+	// returns once the editor has been continued
+	Suspend() error
+}
+
+// a screen that can put the terminal back, when the editor panics
+type Restorer interface {
+	Restore()
+}
+
+// a screen that can be told to repaint everything in its next frame (Ctrl-L)
+type Invalidator interface {
+	Invalidate()
+}
+
+// an event from a goroutine other than the ui goroutine
+//
+// it runs on the ui goroutine, so it can change the editor.
+// an error is shown as a message, only ErrExit stops the editor
+type Event func(e *Editor) error
+
+// input read by the input goroutine
+type input struct {
+	ev  keyboard.Event
+	err error
+}
+
+// the channels the event loop waits on
+type events struct {
+	input  chan input
+	resize chan os.Signal
+	// signals that end the editor (the terminal is in raw mode, so there is no SIGINT)
+	quit chan os.Signal
+	// the events posted to run on the ui goroutine, in order
+	//
+	// posting never blocks (there is no limit), so the ui goroutine can post too
+	mu     sync.Mutex
+	posted []Event
+	// has a value while events are posted
+	wake chan struct{}
+
+	// cancelled when the loop stops, so background work knows to give up
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newEvents() *events {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &events{
+		input:  make(chan input),
+		resize: make(chan os.Signal, 1),
+		quit:   make(chan os.Signal, 1),
+		wake:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// run the editor until it exits
+//
+// waits for input, resizes, timers, file changes and job results, and only redraws
+// once nothing else is waiting, so that typeahead is handled before the screen catches up
+func (e *Editor) Run(s Screen) error {
+	defer e.events.cancel()
+	e.screen = s
+	go e.readInput()
+	signal.Notify(e.events.resize, syscall.SIGWINCH)
+	defer signal.Stop(e.events.resize)
+	signal.Notify(e.events.quit, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(e.events.quit)
+	return e.loop(s)
+}
+
+func (e *Editor) loop(s Screen) error {
+	s.Render(e)
+	for {
+		if _, err := e.next(true); err != nil {
+			return err
+		}
+		for {
+			handled, err := e.next(false)
+			if err != nil {
+				return err
+			}
+			if !handled {
+				break
+			}
+		}
+		if e.suspend {
+			e.suspend = false
+			if err := e.suspendScreen(s); err != nil {
+				return err
+			}
+		}
+		if e.redraw {
+			e.redraw = false
+			if inv, ok := s.(Invalidator); ok {
+				inv.Invalidate()
+			}
+		}
+		s.Render(e)
+	}
+}
+
+func (e *Editor) suspendScreen(s Screen) error {
+	sp, ok := s.(Suspender)
+	if !ok {
+		e.message = "cannot suspend"
+		return nil
+	}
+	e.logger.Debug("suspending")
+	return sp.Suspend()
+}
+
+// handle the next event
+//
+// without block, returns false when there is no event waiting
+func (e *Editor) next(block bool) (bool, error) {
+	if !block {
+		select {
+		case in := <-e.events.input:
+			return true, e.handleInput(in)
+		case <-e.events.resize:
+			return true, e.handleResize()
+		case sig := <-e.events.quit:
+			return true, fmt.Errorf("received %s", sig)
+		case <-e.events.wake:
+			return true, e.handlePosted()
+		default:
+			return false, nil
+		}
+	}
+	select {
+	case in := <-e.events.input:
+		return true, e.handleInput(in)
+	case <-e.events.resize:
+		return true, e.handleResize()
+	case sig := <-e.events.quit:
+		return true, fmt.Errorf("received %s", sig)
+	case <-e.events.wake:
+		return true, e.handlePosted()
+	}
+}
+
+func (e *Editor) handleInput(in input) error {
+	if in.err != nil {
+		return in.err
+	}
+	return e.handleEvent(in.ev)
+}
+
+// the screen reads the new size when it redraws
+func (e *Editor) handleResize() error {
+	e.logger.Debug("terminal resized")
+	return nil
+}
+
+// run the first of the posted events
+func (e *Editor) handlePosted() error {
+	ev := e.events.take()
+	if ev == nil {
+		return nil
+	}
+	err := ev(e)
+	if err != nil && !errors.Is(err, ErrExit) {
+		e.message = err.Error()
+		return nil
+	}
+	return err
+}
+
+// read from the keyboard until it fails
+func (e *Editor) readInput() {
+	defer e.recoverPanic()
+	for {
+		ev, err := e.kb.GetEvent()
+		select {
+		case e.events.input <- input{ev: ev, err: err}:
+		case <-e.events.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// run ev on the ui goroutine
+//
+// safe to call from any goroutine, the ui goroutine included, and never blocks; does nothing
+// once the editor has stopped
+func (e *Editor) Post(ev Event) {
+	if e.events.ctx.Err() != nil {
+		return
+	}
+	e.events.mu.Lock()
+	e.events.posted = append(e.events.posted, ev)
+	e.events.mu.Unlock()
+	e.events.signal()
+}
+
+// wake the loop for the posted events, if it is not going to wake already
+func (ev *events) signal() {
+	select {
+	case ev.wake <- struct{}{}:
+	default:
+	}
+}
+
+// the first posted event, nil if there is none
+func (ev *events) take() Event {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	if len(ev.posted) == 0 {
+		return nil
+	}
+	f := ev.posted[0]
+	ev.posted = ev.posted[1:]
+	if len(ev.posted) > 0 {
+		ev.signal()
+	}
+	return f
+}
+
+// put the terminal back if a goroutine of the editor panics, and let the panic go on
+//
+// the screen only recovers the goroutine it runs on, so every goroutine the editor starts
+// defers this directly
+func (e *Editor) recoverPanic() {
+	if p := recover(); p != nil {
+		if r, ok := e.screen.(Restorer); ok {
+			r.Restore()
+		}
+		panic(p)
+	}
+}
+
+// call f on the ui goroutine after d
+func (e *Editor) After(d time.Duration, f Event) *time.Timer {
+	return time.AfterFunc(d, func() { e.Post(f) })
+}
+
+// run work in the background and hand its result to done on the ui goroutine
+//
+// work must not touch the editor; its context is cancelled when the editor stops
+func RunJob[T any](e *Editor, work func(ctx context.Context) (T, error), done func(e *Editor, res T, err error) error) {
+	go func() {
+		defer e.recoverPanic()
+		res, err := work(e.events.ctx)
+		e.Post(func(e *Editor) error { return done(e, res, err) })
+	}()
+}
+
+// call f on the ui goroutine whenever the file at path changes
+//
+// the file is checked every interval, a file that goes missing is reported with a nil FileInfo.
+// returns a function that stops watching
+func (e *Editor) Watch(path string, interval time.Duration, f func(e *Editor, fi fs.FileInfo) error) (stop func()) {
+	ctx, cancel := context.WithCancel(e.events.ctx)
+	last, _ := os.Stat(path)
+	go func() {
+		defer e.recoverPanic()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+			fi, err := os.Stat(path)
+			if err != nil {
+				fi = nil
+			}
+			if !changed(last, fi) {
+				continue
+			}
+			last = fi
+			e.logger.Debug("file changed", slog.String("path", path))
+			e.Post(func(e *Editor) error { return f(e, fi) })
+		}
+	}()
+	return cancel
+}
+
+func changed(a, b fs.FileInfo) bool {
+	if a == nil || b == nil {
+		return a != b
+	}
+	return !a.ModTime().Equal(b.ModTime()) || a.Size() != b.Size()
+}
@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+type countScreen struct {
+	renders int
+	// post exit once there have been that many frames, 0 for never
+	exitAfter int
+}
+
+func (s *countScreen) Render(e *Editor) {
+	s.renders++
+	if s.renders == s.exitAfter {
+		e.Post(exit)
+	}
+}
+
+func exit(e *Editor) error { return ErrExit }
+
+func TestLoop_BatchesRedraws(t *testing.T) {
+	e := newTestEditor("")
+	for _, k := range "iabc" {
+		e.Post(func(e *Editor) error { return e.handleKey(keyboard.Key(k)) })
+	}
+	s := &countScreen{exitAfter: 2}
+	if err := e.loop(s); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if got := rowsOf(e); got[0] != "abc" {
+		t.Errorf("rows = %q, want abc", got)
+	}
+	// the first frame, then one for all of the typeahead (the exit comes after it)
+	if s.renders != 2 {
+		t.Errorf("renders = %d, want 2", s.renders)
+	}
+}
+
+func TestLoop_PostFromTheLoop(t *testing.T) {
+	e := newTestEditor("")
+	n := 0
+	// posting from the ui goroutine cannot wait for the loop, however many events are waiting
+	e.Post(func(e *Editor) error {
+		for range 1000 {
+			e.Post(func(e *Editor) error { n++; return nil })
+		}
+		e.Post(exit)
+		return nil
+	})
+	if err := e.loop(&countScreen{}); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if n != 1000 {
+		t.Errorf("%d events ran, want 1000", n)
+	}
+}
+
+func TestLoop_Job(t *testing.T) {
+	e := newTestEditor("")
+	RunJob(e, func(ctx context.Context) (string, error) {
+		return "", errors.New("job failed")
+	}, func(e *Editor, res string, err error) error {
+		defer e.Post(exit)
+		return err
+	})
+	if err := e.loop(&countScreen{}); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if e.Message() != "job failed" {
+		t.Errorf("message = %q, want the job's error", e.Message())
+	}
+}
+
+func TestLoop_Watch(t *testing.T) {
+	e := newTestEditor("")
+	path := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(path, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	e.Watch(path, time.Millisecond, func(e *Editor, fi fs.FileInfo) error {
+		size = fi.Size()
+		return ErrExit
+	})
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.loop(&countScreen{}); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if size != 3 {
+		t.Errorf("size = %d, want 3", size)
+	}
+}
+
+// stops the editor after its second frame
+type invalidateScreen struct {
+	renders     int
+	invalidated int
+}
+
+func (s *invalidateScreen) Invalidate() { s.invalidated++ }
+func (s *invalidateScreen) Render(e *Editor) {
+	s.renders++
+	if s.renders == 2 {
+		e.Post(exit)
+	}
+}
+
+func TestLoop_Redraw(t *testing.T) {
+	e := newTestEditor("")
+	e.Post(func(e *Editor) error { return e.handleKey(keyboard.CtrlL) })
+	s := &invalidateScreen{}
+	if err := e.loop(s); !errors.Is(err, ErrExit) {
+		t.Fatalf("error = %v, want ErrExit", err)
+	}
+	if s.invalidated != 1 {
+		t.Errorf("invalidated = %d, want 1", s.invalidated)
+	}
+}
+
+type restoreScreen struct {
+	countScreen
+	restored bool
+}
+
+func (s *restoreScreen) Restore() { s.restored = true }
+
+func TestRecoverPanic_RestoresTheScreen(t *testing.T) {
+	e := newTestEditor("a")
+	s := &restoreScreen{}
+	e.screen = s
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v, want the panic to go on", p)
+		}
+		if !s.restored {
+			t.Error("the screen was not restored")
+		}
+	}()
+	func() {
+		defer e.recoverPanic()
+		panic("boom")
+	}()
+}
@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+// stop runaway macros (e.g. a macro that plays itself)
+const maxMacroDepth = 100
+
+var ErrMacroDepth = fmt.Errorf("macro recursion too deep: %w", ErrFailed)
+
+// keeps track of macro recording and playback
+type macroRecorder struct {
+	// the register being recorded into, 0 when not recording
+	register rune
+	keys     keyboard.OrderedKeyList
+	// the last register that was played, for @@
+	last  rune
+	depth int
+}
+
+func (m *macroRecorder) recording() bool {
+	return m.register != 0
+}
+
+func (m *macroRecorder) start(reg rune) {
+	m.register = reg
+	m.keys = keyboard.OrderedKeyList{}
+}
+
+// stop the recording and return the recorded keys as a register
+func (m *macroRecorder) stop() (rune, Register) {
+	reg := m.register
+	m.register = 0
+	return reg, Register{Rows: [][]rune{[]rune(m.keys.Text())}}
+}
+
+// the register that is currently being recorded into
+//
+// returns 0 if nothing is being recorded
+func (e *Editor) Recording() rune {
+	return e.macro.register
+}
+
+// play the keys in a register count times
+//
+// playback stops at the first action that fails
+func (e *Editor) playMacro(reg rune, count int) error {
+	if reg == '@' {
+		reg = e.macro.last
+		if reg == 0 {
+			return nil
+		}
+	}
+	r, ok := e.regs.Get(reg)
+	if !ok {
+		return nil
+	}
+	e.macro.last = reg
+	if e.macro.depth >= maxMacroDepth {
+		return ErrMacroDepth
+	}
+	e.macro.depth++
+	defer func() { e.macro.depth-- }()
+	// a line yanked into the register is played without its line break, which would be an
+	// extra Enter
+	keys := keyboard.ParseKeys(strings.TrimSuffix(r.Text(), "\n"))
+	for range max(count, 1) {
+		if err := e.feed(keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func TestMacro(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		keys keyboard.OrderedKeyList
+		want []string
+	}{
+		{
+			name: "record and play",
+			rows: []string{"a", "b", "c", "d"},
+			keys: keyboard.ParseKeys("qaddqj@a"),
+			want: []string{"b", "d"},
+		},
+		{
+			name: "play with count",
+			rows: []string{"1", "2", "3", "4", "5", "6", "7"},
+			keys: keyboard.ParseKeys("qaddjq2@a"),
+			want: []string{"2", "4", "6", "7"},
+		},
+		{
+			name: "play last macro",
+			rows: []string{"a", "b", "c"},
+			keys: keyboard.ParseKeys("qaddq@a@@"),
+			want: []string{""},
+		},
+		{
+			name: "stop at failing motion",
+			rows: []string{"a", "b", "c"},
+			keys: keyboard.ParseKeys("qajddq5@a"),
+			want: []string{"a", "c"},
+		},
+		{
+			name: "edit register as text",
+			rows: []string{"a", "b", "c", "d"},
+			keys: keyboard.ParseKeys("qajqo<ESC>\"apidd<ESC>\"ayydd@a"),
+			want: []string{"a", "b", "d"},
+		},
+		{
+			name: "play a yanked line without its line break",
+			rows: []string{"dd", "a", "b", "c", "d"},
+			keys: keyboard.ParseKeys("\"ayyj2@a"),
+			want: []string{"dd", "c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, tt.keys...)
+			got := rowsOf(e)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rows %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMacroRegisterText(t *testing.T) {
+	e := newTestEditor("a")
+	typeKeys(t, e, keyboard.ParseKeys("qbix<ESC>q")...)
+	reg, ok := e.regs.Get('b')
+	if !ok {
+		t.Fatal("register b is empty")
+	}
+	if got := reg.Text(); got != "ix<ESC>" {
+		t.Errorf("register text = %q, want %q", got, "ix<ESC>")
+	}
+	if e.Recording() != 0 {
+		t.Errorf("still recording into %q", e.Recording())
+	}
+}
+
+func TestDeleteLastLine(t *testing.T) {
+	tests := []struct {
+		name string
+		keys keyboard.OrderedKeyList
+		want []string
+	}{
+		{name: "put after", keys: keyboard.ParseKeys("ddp"), want: []string{"", "only"}},
+		{name: "put before", keys: keyboard.ParseKeys("ddP"), want: []string{"only", ""}},
+		{name: "insert", keys: keyboard.ParseKeys("ddix<ESC>"), want: []string{"x"}},
+		{name: "count past the end", keys: keyboard.ParseKeys("3ddp"), want: []string{"", "only"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor("only")
+			typeKeys(t, e, tt.keys...)
+			got := rowsOf(e)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rows %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
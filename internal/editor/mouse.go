@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"github.com/jcocozza/jte/internal/keyboard"
+	"github.com/jcocozza/jte/internal/mode"
+)
+
+// the number of rows one turn of the mouse wheel scrolls
+const scrollRows = 3
+
+type mouseState struct {
+	// the split whose border is being dragged
+	border *SplitNode
+}
+
+// make n the active node
+//
+// keeps Pane.Active, Editor.Active, the current buffer and its view in sync
+func (e *Editor) Focus(n *SplitNode) {
+	if n == nil || n.Pane == nil {
+		return
+	}
+	if e.Active != nil && e.Active.Pane != nil {
+		e.Active.Pane.Active = false
+	}
+	e.Active = n
+	n.Pane.Active = true
+	if e.BM.Current == nil || e.BM.Current.Buf != n.Pane.Buf {
+		e.BM.SetCurrent(n.Pane.Buf.ID())
+	}
+	n.Pane.Buf.SetView(n.Pane.view())
+}
+
+func (e *Editor) handleMouse(m *keyboard.Mouse) error {
+	if !e.Options.Mouse || e.Root == nil {
+		return nil
+	}
+	switch m.Action {
+	case keyboard.MouseScroll:
+		switch m.Button {
+		case keyboard.WheelUp:
+			e.scroll(e.Root.Leaf(m.X, m.Y), -scrollRows)
+		case keyboard.WheelDown:
+			e.scroll(e.Root.Leaf(m.X, m.Y), scrollRows)
+		}
+	case keyboard.MousePress:
+		if m.Button != keyboard.MouseLeft {
+			return nil
+		}
+		if b := e.Root.Border(m.X, m.Y); b != nil {
+			e.mouse.border = b
+			return nil
+		}
+		n := e.Root.Leaf(m.X, m.Y)
+		if n == nil {
+			return nil
+		}
+		if e.m.Current() == mode.Visual || e.m.Current() == mode.Command || n != e.Active {
+			if err := (SwitchMode{m: mode.Normal}).Apply(e); err != nil {
+				return err
+			}
+		}
+		e.Focus(n)
+		e.moveCursorTo(n, m.X, m.Y)
+	case keyboard.MouseDrag:
+		if m.Button != keyboard.MouseLeft {
+			return nil
+		}
+		if e.mouse.border != nil {
+			e.mouse.border.MoveBorder(m.X, m.Y)
+			return nil
+		}
+		if e.m.Current() != mode.Visual {
+			if err := (SwitchMode{m: mode.Visual}).Apply(e); err != nil {
+				return err
+			}
+		}
+		// the selection stays in the pane it started in
+		r := e.Active.Rect
+		x := min(max(m.X, r.X), r.X+r.Cols-1)
+		y := min(max(m.Y, r.Y), r.Y+e.Active.TextRows()-1)
+		e.moveCursorTo(e.Active, x, y)
+	case keyboard.MouseRelease:
+		e.mouse.border = nil
+	}
+	return nil
+}
+
+// move the cursor to the text shown at x, y on the screen
+func (e *Editor) moveCursorTo(n *SplitNode, x, y int) {
+	if y-n.Rect.Y >= n.TextRows() {
+		return // the status line
+	}
+	buf := n.Pane.Buf
+	if len(buf.Rows) == 0 {
+		return
+	}
+	// a click on the gutter is on the first column shown
+	x -= n.Rect.X + n.Pane.GutterWidth(n.Rect.Cols)
+	buf.SetCursor(n.Pane.PositionAt(x, y-n.Rect.Y, n.TextCols()))
+}
+
+// scroll the pane by delta rows
+//
+// the cursor of the active pane is kept inside the pane
+func (e *Editor) scroll(n *SplitNode, delta int) {
+	if n == nil {
+		return
+	}
+	p := n.Pane
+	p.RowOffset = min(max(p.RowOffset+delta, 0), max(len(p.Buf.Rows)-1, 0))
+	p.SegOffset = 0
+	if n != e.Active {
+		return
+	}
+	buf := p.Buf
+	switch {
+	case buf.Y() < p.RowOffset:
+		buf.SetCursor(buf.X(), p.RowOffset)
+	case buf.Y() >= p.RowOffset+n.TextRows():
+		buf.SetCursor(buf.X(), p.RowOffset+n.TextRows()-1)
+	}
+}
@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func click(t *testing.T, e *Editor, action keyboard.MouseAction, button keyboard.MouseButton, x, y int) {
+	t.Helper()
+	if err := e.handleMouse(&keyboard.Mouse{Action: action, Button: button, X: x, Y: y}); err != nil {
+		t.Fatalf("mouse: %v", err)
+	}
+}
+
+func TestMouse_ClickFocusesPane(t *testing.T) {
+	e := newTestEditor("zero", "one", "two\tx")
+	typeKeys(t, e, 'v')
+	e.Root.Resize(Rect{Rows: 10, Cols: 41})
+	right := e.Root.Second
+	if right.Rect.X != 20 {
+		t.Fatalf("right pane at %d, want 20", right.Rect.X)
+	}
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, right.Rect.X+8, 2)
+	if e.Active != right || !right.Pane.Active || e.Root.First.Pane.Active {
+		t.Fatalf("clicked pane is not active")
+	}
+	buf := e.BM.Current.Buf
+	// column 8 is the x after the tab
+	if buf.X() != 4 || buf.Y() != 2 {
+		t.Errorf("cursor = %d,%d, want 4,2", buf.X(), buf.Y())
+	}
+}
+
+func TestMouse_Scroll(t *testing.T) {
+	rows := make([]string, 20)
+	e := newTestEditor(rows...)
+	e.Root.Resize(Rect{Rows: 5, Cols: 10})
+	click(t, e, keyboard.MouseScroll, keyboard.WheelDown, 1, 1)
+	if e.Active.Pane.RowOffset != scrollRows {
+		t.Errorf("row offset = %d, want %d", e.Active.Pane.RowOffset, scrollRows)
+	}
+	if y := e.BM.Current.Buf.Y(); y != scrollRows {
+		t.Errorf("cursor was not kept in view: %d", y)
+	}
+	click(t, e, keyboard.MouseScroll, keyboard.WheelUp, 1, 1)
+	click(t, e, keyboard.MouseScroll, keyboard.WheelUp, 1, 1)
+	if e.Active.Pane.RowOffset != 0 {
+		t.Errorf("row offset = %d, want 0", e.Active.Pane.RowOffset)
+	}
+}
+
+func TestMouse_DragSelects(t *testing.T) {
+	e := newTestEditor("hello world", "second")
+	e.Root.Resize(Rect{Rows: 5, Cols: 20})
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 6, 0)
+	click(t, e, keyboard.MouseDrag, keyboard.MouseLeft, 8, 0)
+	click(t, e, keyboard.MouseDrag, keyboard.MouseLeft, 2, 1)
+	click(t, e, keyboard.MouseRelease, keyboard.MouseLeft, 2, 1)
+	if e.Selection() == nil {
+		t.Fatal("no selection after drag")
+	}
+	typeKeys(t, e, 'y')
+	reg, _ := e.regs.Get(unnamedRegister)
+	if got := reg.Text(); got != "world\nsec" {
+		t.Errorf("yanked %q, want %q", got, "world\nsec")
+	}
+}
+
+func TestMouse_DragBorder(t *testing.T) {
+	e := newTestEditor("a")
+	typeKeys(t, e, 's')
+	e.Root.Resize(Rect{Rows: 20, Cols: 10})
+	border := e.Root.First.Rect.Rows - 1
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 3, border)
+	click(t, e, keyboard.MouseDrag, keyboard.MouseLeft, 3, 4)
+	click(t, e, keyboard.MouseRelease, keyboard.MouseLeft, 3, 4)
+	if e.Root.FirstRatio != 0.25 {
+		t.Errorf("ratio = %v, want 0.25", e.Root.FirstRatio)
+	}
+	if e.Root.First.Rect.Rows != 5 {
+		t.Errorf("first pane has %d rows, want 5", e.Root.First.Rect.Rows)
+	}
+}
+
+func TestMouse_Off(t *testing.T) {
+	e := newTestEditor("a", "b")
+	e.Root.Resize(Rect{Rows: 5, Cols: 10})
+	typeKeys(t, e, keyboard.ParseKeys(":set nomouse<ENTER>")...)
+	if e.Options.Mouse {
+		t.Fatal("mouse is still on")
+	}
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 0, 1)
+	if e.BM.Current.Buf.Y() != 0 {
+		t.Errorf("click moved the cursor with the mouse off")
+	}
+}
+
+func TestMouse_ClickPastGutter(t *testing.T) {
+	e := newTestEditor("zero", "one")
+	typeKeys(t, e, keyboard.ParseKeys(":set number<ENTER>")...)
+	e.Root.Resize(Rect{Rows: 5, Cols: 20})
+	// the numbers take up 4 columns
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 6, 0)
+	if buf := e.BM.Current.Buf; buf.X() != 2 || buf.Y() != 0 {
+		t.Errorf("cursor = %d,%d, want 2,0", buf.X(), buf.Y())
+	}
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 1, 1)
+	if buf := e.BM.Current.Buf; buf.X() != 0 || buf.Y() != 1 {
+		t.Errorf("a click on the gutter: cursor = %d,%d, want 0,1", buf.X(), buf.Y())
+	}
+}
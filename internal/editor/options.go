@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// settings that can be changed with :set
+type Options struct {
+	// handle mouse events (turn off to use the terminal's own selection)
+	Mouse bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Mouse: true,
+	}
+}
+
+// options by name, that :set changes
+type optionSet struct {
+	bools   map[string]*bool
+	strings map[string]*string
+}
+
+// add the options of o to s
+func (s optionSet) merge(o optionSet) {
+	maps.Copy(s.bools, o.bools)
+	maps.Copy(s.strings, o.strings)
+}
+
+func (o *Options) options() optionSet {
+	return optionSet{
+		bools: map[string]*bool{
+			"mouse": &o.Mouse,
+		},
+		strings: map[string]*string{},
+	}
+}
+
+// apply a :set argument
+//
+//	name       turn the option on
+//	noname     turn the option off
+//	invname    toggle the option
+//	name=value set a string option
+//	name?      show the option
+//
+// returns a message to show, if any
+func (o *Options) Set(arg string) (string, error) {
+	return o.options().set(arg)
+}
+
+// the options of a pane, they are set for each pane
+func (p *Pane) options() optionSet {
+	g := p.Gutter()
+	return optionSet{
+		bools: map[string]*bool{
+			"number":         &g.Number,
+			"nu":             &g.Number,
+			"relativenumber": &g.RelativeNumber,
+			"rnu":            &g.RelativeNumber,
+			"signcolumn":     &g.SignColumn,
+			"wrap":           &p.Wrap,
+			"linebreak":      &p.WrapOptions.LineBreak,
+			"lbr":            &p.WrapOptions.LineBreak,
+			"breakindent":    &p.WrapOptions.BreakIndent,
+			"bri":            &p.WrapOptions.BreakIndent,
+		},
+		strings: map[string]*string{
+			"showbreak": &p.WrapOptions.ShowBreak,
+			"sbr":       &p.WrapOptions.ShowBreak,
+		},
+	}
+}
+
+func (s optionSet) set(arg string) (string, error) {
+	if name, value, ok := strings.Cut(arg, "="); ok {
+		v, ok := s.strings[name]
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		*v = value
+		return "", nil
+	}
+	if name, ok := strings.CutSuffix(arg, "?"); ok {
+		if v, ok := s.strings[name]; ok {
+			return name + "=" + *v, nil
+		}
+		b, ok := s.bools[name]
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		if *b {
+			return name, nil
+		}
+		return "no" + name, nil
+	}
+	if b, ok := s.bools[arg]; ok {
+		*b = true
+		return "", nil
+	}
+	if name, ok := strings.CutPrefix(arg, "no"); ok {
+		if b, ok := s.bools[name]; ok {
+			*b = false
+			return "", nil
+		}
+	}
+	if name, ok := strings.CutPrefix(arg, "inv"); ok {
+		if b, ok := s.bools[name]; ok {
+			*b = !*b
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("unknown option: %s", arg)
+}
+
+// split the arguments of :set at blanks, a blank or a backslash after a backslash is part of
+// the argument (e.g. :set showbreak=>\ )
+func setArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	rs := []rune(line)
+	for i := 0; i < len(rs); i++ {
+		switch {
+		case rs[i] == '\\' && i+1 < len(rs) && strings.ContainsRune(" \t\\", rs[i+1]):
+			i++
+			arg.WriteRune(rs[i])
+		case rs[i] == ' ' || rs[i] == '\t':
+			if arg.Len() > 0 {
+				args = append(args, arg.String())
+				arg.Reset()
+			}
+		default:
+			arg.WriteRune(rs[i])
+		}
+	}
+	if arg.Len() > 0 {
+		args = append(args, arg.String())
+	}
+	return args
+}
@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func TestPaste(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   []string
+		before keyboard.OrderedKeyList
+		paste  string
+		after  keyboard.OrderedKeyList
+		want   []string
+	}{
+		{
+			name:  "normal mode",
+			rows:  []string{"ad"},
+			paste: "b\r\nc",
+			want:  []string{"b", "cad"},
+		},
+		{
+			name:   "insert mode",
+			rows:   []string{"ad"},
+			before: keyboard.ParseKeys("il"),
+			paste:  "b\rc",
+			after:  keyboard.ParseKeys("<ESC>"),
+			want:   []string{"lb", "cad"},
+		},
+		{
+			name:  "repeat a paste",
+			rows:  []string{""},
+			paste: "x\ny",
+			after: keyboard.ParseKeys("."),
+			want:  []string{"x", "yx", "y"},
+		},
+		{
+			name:   "paste in a macro",
+			rows:   []string{"", ""},
+			before: keyboard.ParseKeys("qa"),
+			paste:  "z",
+			after:  keyboard.ParseKeys("qj@a"),
+			want:   []string{"z", "z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, tt.before...)
+			if err := e.handlePaste(tt.paste); err != nil {
+				t.Fatalf("paste: %v", err)
+			}
+			typeKeys(t, e, tt.after...)
+			got := rowsOf(e)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rows %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
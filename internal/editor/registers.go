@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// the register used when no register is given
+const unnamedRegister = '"'
+
+// a register holds text that was yanked, deleted or recorded
+type Register struct {
+	Rows [][]rune
+	// linewise registers are put as whole lines
+	Linewise bool
+}
+
+// the register contents as a single string, rows are joined by new lines
+func (r Register) Text() string {
+	lines := make([]string, len(r.Rows))
+	for i, row := range r.Rows {
+		lines[i] = string(row)
+	}
+	text := strings.Join(lines, "\n")
+	if r.Linewise {
+		text += "\n"
+	}
+	return text
+}
+
+type Registers struct {
+	regs map[rune]Register
+}
+
+func NewRegisters() *Registers {
+	return &Registers{regs: map[rune]Register{}}
+}
+
+func validRegister(name rune) bool {
+	return name == unnamedRegister || (name >= 'a' && name <= 'z') || (name >= 'A' && name <= 'Z')
+}
+
+func (r *Registers) Get(name rune) (Register, bool) {
+	reg, ok := r.regs[unicode.ToLower(name)]
+	return reg, ok
+}
+
+// set the contents of a register
+//
+// an upper case name appends to the register; every write also goes to the unnamed register
+func (r *Registers) Set(name rune, reg Register) error {
+	if !validRegister(name) {
+		return fmt.Errorf("invalid register: %q", name)
+	}
+	if unicode.IsUpper(name) {
+		name = unicode.ToLower(name)
+		if prev, ok := r.regs[name]; ok {
+			reg = Register{
+				Rows:     append(append([][]rune{}, prev.Rows...), reg.Rows...),
+				Linewise: prev.Linewise || reg.Linewise,
+			}
+		}
+	}
+	r.regs[name] = reg
+	r.regs[unnamedRegister] = reg
+	return nil
+}
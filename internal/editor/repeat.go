@@ -0,0 +1,120 @@
+package editor
+
+import (
+	"strconv"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+	"github.com/jcocozza/jte/internal/mode"
+)
+
+// a change is the group of keys that produced the last edit to the buffer
+//
+// this includes the command (e.g. dd, o), and any text typed in insert mode afterwards
+type change struct {
+	keys  keyboard.OrderedKeyList
+	count int
+	// the register selected for the change (e.g. "ap), 0 for none
+	register rune
+}
+
+// keeps track of the last change so that it can be replayed with '.'
+type changeRecorder struct {
+	last *change
+	// a change that is still in progress (e.g. we are still in insert mode)
+	pending *change
+	// the register selected for the next change
+	register  rune
+	replaying bool
+}
+
+// whether the action changes the buffer
+//
+// only changes are recorded for '.'
+func isChange(a Action) bool {
+	switch a := a.(type) {
+	case SwitchMode:
+		return a.m == mode.Insert || a.m == mode.Replace
+	case Insert, Paste, EnterNewLine, NewLineAbove, NewLineBelow, Backspace, Delete, DeleteLine, Put,
+		ReplaceChar, ReplaceBackspace, ReplaceNewLine:
+		return true
+	}
+	return false
+}
+
+// called before a key is dispatched
+func (r *changeRecorder) key(k keyboard.Key, m mode.Mode) {
+	if r.replaying || r.pending == nil || (m != mode.Insert && m != mode.Replace) {
+		return
+	}
+	r.pending.keys.Append(k)
+}
+
+// called after the actions of a dispatch have been applied
+//
+// m is the mode the dispatch happened in, curr is the mode we are in now
+func (r *changeRecorder) dispatched(keys keyboard.OrderedKeyList, count int, actions []Action, m mode.Mode, curr mode.Mode) {
+	if r.replaying {
+		return
+	}
+	if m == mode.Normal {
+		register := r.register
+		r.register = 0
+		for _, a := range actions {
+			if sel, ok := a.(SelectRegister); ok {
+				r.register = sel.r
+			}
+			if isChange(a) {
+				r.pending = &change{
+					keys:     append(keyboard.OrderedKeyList{}, keys...),
+					count:    count,
+					register: register,
+				}
+				break
+			}
+		}
+	}
+	if r.pending != nil && curr == mode.Normal {
+		r.last = r.pending
+		r.pending = nil
+	}
+}
+
+// a paste is recorded as the keys that would have been typed
+//
+// in normal mode the keys include entering and leaving insert mode
+func (r *changeRecorder) paste(keys keyboard.OrderedKeyList, m mode.Mode) {
+	if r.replaying {
+		return
+	}
+	switch m {
+	case mode.Insert, mode.Replace:
+		if r.pending != nil {
+			r.pending.keys = append(r.pending.keys, keys...)
+		}
+	case mode.Normal:
+		r.last = &change{keys: keys}
+	}
+}
+
+// the keys to replay the last change
+//
+// if count is not 0, it replaces the count of the original change
+func (r *changeRecorder) replay(count int) keyboard.OrderedKeyList {
+	if r.last == nil {
+		return nil
+	}
+	if count == 0 {
+		count = r.last.count
+	}
+	keys := keyboard.OrderedKeyList{}
+	if r.last.register != 0 {
+		keys.Append('"')
+		keys.Append(keyboard.Key(r.last.register))
+	}
+	if count > 1 {
+		for _, c := range strconv.Itoa(count) {
+			keys.Append(keyboard.Key(c))
+		}
+	}
+	return append(keys, r.last.keys...)
+}
@@ -0,0 +1,138 @@
+package editor
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+func newTestEditor(rows ...string) *Editor {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := NewEditor(l)
+	bufrows := make([]buffer.BufRow, len(rows))
+	for i, r := range rows {
+		bufrows[i] = buffer.BufRow(r)
+	}
+	buf := buffer.NewBuffer("test", "", false, bufrows, l)
+	id := e.BM.Add(buf)
+	e.BM.SetCurrent(id)
+	e.Root = &SplitNode{Pane: &Pane{Buf: buf}}
+	e.Focus(e.Root)
+	return e
+}
+
+func typeKeys(t *testing.T, e *Editor, keys ...keyboard.Key) {
+	t.Helper()
+	for _, k := range keys {
+		if err := e.handleKey(k); err != nil {
+			t.Fatalf("key %q: %v", k, err)
+		}
+	}
+}
+
+func rowsOf(e *Editor) []string {
+	rows := []string{}
+	for _, r := range e.BM.Current.Buf.Rows {
+		rows = append(rows, string(r))
+	}
+	return rows
+}
+
+func TestRepeatChange(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		keys []keyboard.Key
+		want []string
+	}{
+		{
+			name: "repeat delete line",
+			rows: []string{"a", "b", "c", "d"},
+			keys: []keyboard.Key{'d', 'd', '.'},
+			want: []string{"c", "d"},
+		},
+		{
+			name: "repeat keeps count",
+			rows: []string{"a", "b", "c", "d", "e"},
+			keys: []keyboard.Key{'2', 'd', 'd', '.'},
+			want: []string{"e"},
+		},
+		{
+			name: "new count replaces original",
+			rows: []string{"a", "b", "c", "d", "e", "f"},
+			keys: []keyboard.Key{'2', 'd', 'd', '3', '.'},
+			want: []string{"f"},
+		},
+		{
+			name: "repeat inserted text",
+			rows: []string{""},
+			keys: []keyboard.Key{'i', 'a', 'b', keyboard.ESC, '.'},
+			want: []string{"abab"},
+		},
+		{
+			name: "repeat open line",
+			rows: []string{"x"},
+			keys: []keyboard.Key{'o', 'y', keyboard.ESC, '.'},
+			want: []string{"x", "y", "y"},
+		},
+		{
+			name: "repeat from the register it put",
+			rows: []string{"a", "b"},
+			keys: []keyboard.Key{'"', 'a', 'y', 'y', 'j', 'y', 'y', '"', 'a', 'p', '.'},
+			want: []string{"a", "b", "a", "a"},
+		},
+		{
+			name: "motions are not recorded",
+			rows: []string{"a", "b", "c"},
+			keys: []keyboard.Key{'d', 'd', 'j', '.'},
+			want: []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, tt.keys...)
+			got := rowsOf(e)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rows %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		keys keyboard.OrderedKeyList
+		want []string
+	}{
+		{name: "before the operator", rows: []string{"a", "b", "c", "d"}, keys: keyboard.ParseKeys("2dd"), want: []string{"c", "d"}},
+		{name: "after the operator", rows: []string{"a", "b", "c", "d"}, keys: keyboard.ParseKeys("d2d"), want: []string{"c", "d"}},
+		{name: "with a zero", rows: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}, keys: keyboard.ParseKeys("d10d"), want: []string{"11"}},
+		{name: "a register is not a count", rows: []string{"a", "b"}, keys: keyboard.ParseKeys("\"1ddj\"1p"), want: []string{"b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, tt.keys...)
+			got := rowsOf(e)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got rows %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
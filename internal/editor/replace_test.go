@@ -0,0 +1,86 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+	"github.com/jcocozza/jte/internal/mode"
+)
+
+func TestReplaceMode(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		keys []keyboard.Key
+		want []string
+	}{
+		{
+			name: "types over",
+			rows: []string{"abcd"},
+			keys: []keyboard.Key{'R', 'x', 'y', keyboard.ESC},
+			want: []string{"xycd"},
+		},
+		{
+			name: "adds past the end of the line",
+			rows: []string{"ab", "c"},
+			keys: []keyboard.Key{'R', 'x', 'y', 'z', keyboard.ESC},
+			want: []string{"xyz", "c"},
+		},
+		{
+			name: "backspace puts back what was typed over",
+			rows: []string{"abc"},
+			keys: []keyboard.Key{'R', 'x', 'y', 'z', 'w', keyboard.BACKSPACE_2, keyboard.BACKSPACE_2, keyboard.ESC},
+			want: []string{"xyc"},
+		},
+		{
+			name: "new line does not type over",
+			rows: []string{"abc"},
+			keys: []keyboard.Key{'R', 'x', keyboard.ENTER, 'y', keyboard.ESC},
+			want: []string{"x", "yc"},
+		},
+		{
+			name: "repeat",
+			rows: []string{"abcd", "efgh"},
+			keys: []keyboard.Key{'R', 'x', 'y', keyboard.ESC, 'j', '.'},
+			want: []string{"xycd", "efxy"},
+		},
+	}
+	pastes := []struct {
+		name  string
+		rows  []string
+		paste string
+		keys  []keyboard.Key
+		want  []string
+	}{
+		{"paste types over", []string{"abcdef"}, "xy", []keyboard.Key{'z', keyboard.ESC}, []string{"xyzdef"}},
+		{"paste a new line", []string{"abcd"}, "x\ny", []keyboard.Key{'z', keyboard.ESC}, []string{"x", "yzd"}},
+		{"backspace after a paste", []string{"abcd"}, "xy", []keyboard.Key{keyboard.BACKSPACE_2, keyboard.ESC}, []string{"xbcd"}},
+		{"type past the end after a paste", []string{"ab", "c"}, "xyz", []keyboard.Key{'w', keyboard.ESC}, []string{"xyzw", "c"}},
+	}
+	for _, tt := range pastes {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, 'R')
+			if err := e.handlePaste(tt.paste); err != nil {
+				t.Fatal(err)
+			}
+			typeKeys(t, e, tt.keys...)
+			if got := rowsOf(e); !slices.Equal(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(tt.rows...)
+			typeKeys(t, e, tt.keys...)
+			if got := rowsOf(e); !slices.Equal(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if e.m.Current() != mode.Normal {
+				t.Errorf("mode = %s, want normal", e.m.Current())
+			}
+		})
+	}
+}
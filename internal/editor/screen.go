@@ -0,0 +1,274 @@
+package editor
+
+import (
+	"github.com/jcocozza/jte/internal/buffer"
+)
+
+// where the text of a pane is on the screen
+//
+// width is the number of columns of the pane available for text (see SplitNode.TextCols),
+// screen positions are relative to the top left of the text
+
+// the screen lines of buffer row y, a row is one screen line when the pane does not wrap
+func (p *Pane) Segments(y int, width int) []buffer.Segment {
+	row := p.Buf.Rows[y]
+	if !p.Wrap {
+		return []buffer.Segment{{End: len(row)}}
+	}
+	return row.Wrap(width, p.WrapOptions)
+}
+
+// the screen line of segment seg of row y, counted from the top of the pane
+func (p *Pane) lineOf(y int, seg int, width int) int {
+	if y == p.RowOffset {
+		return seg - p.SegOffset
+	}
+	n := len(p.Segments(p.RowOffset, width)) - p.SegOffset
+	for r := p.RowOffset + 1; r < y; r++ {
+		n += len(p.Segments(r, width))
+	}
+	return n + seg
+}
+
+// scroll so that the pane's cursor is inside a pane of rows by width cells of text
+func (p *Pane) Scroll(rows int, width int) {
+	buf, cur := p.Buf, p.Cursor()
+	// there are no lines to scroll the cursor into (e.g. only the status line fits)
+	if rows < 1 {
+		return
+	}
+	if !p.Wrap {
+		p.SegOffset = 0
+		if cur.Y < p.RowOffset {
+			p.RowOffset = cur.Y
+		}
+		if cur.Y >= p.RowOffset+rows {
+			p.RowOffset = cur.Y - rows + 1
+		}
+		col := 0
+		if cur.Y < len(buf.Rows) {
+			col = buf.Rows[cur.Y].DisplayCol(cur.X)
+		}
+		if col < p.ColOffset {
+			p.ColOffset = col
+		}
+		if col >= p.ColOffset+width {
+			p.ColOffset = col - width + 1
+		}
+		return
+	}
+	p.ColOffset = 0
+	if cur.Y >= len(buf.Rows) {
+		p.RowOffset, p.SegOffset = 0, 0
+		return
+	}
+	// the rows may have been deleted from under the offset, e.g. through another pane
+	p.RowOffset = max(min(p.RowOffset, len(buf.Rows)-1), 0)
+	y := cur.Y
+	seg := buffer.SegmentAt(p.Segments(y, width), cur.X)
+	if y < p.RowOffset || (y == p.RowOffset && seg < p.SegOffset) {
+		p.RowOffset, p.SegOffset = y, seg
+		return
+	}
+	// the row at the top may have been made shorter
+	p.SegOffset = min(p.SegOffset, len(p.Segments(p.RowOffset, width))-1)
+	// every row takes up a line at least, so the rows further up than that are out of view
+	if y-p.RowOffset >= rows {
+		p.RowOffset, p.SegOffset = y-rows+1, 0
+	}
+	for n := p.lineOf(y, seg, width); n >= max(rows, 1); n-- {
+		if p.SegOffset+1 < len(p.Segments(p.RowOffset, width)) {
+			p.SegOffset++
+		} else {
+			p.RowOffset, p.SegOffset = p.RowOffset+1, 0
+		}
+	}
+}
+
+// where the pane's cursor is on the screen
+//
+// it is only in the pane after the pane has been scrolled to it
+func (p *Pane) CursorAt(width int) (x int, y int) {
+	buf, cur := p.Buf, p.Cursor()
+	if cur.Y >= len(buf.Rows) {
+		return 0, cur.Y - p.RowOffset
+	}
+	col := buf.Rows[cur.Y].DisplayCol(cur.X)
+	if !p.Wrap {
+		return col - p.ColOffset, cur.Y - p.RowOffset
+	}
+	segs := p.Segments(cur.Y, width)
+	i := buffer.SegmentAt(segs, cur.X)
+	// past the end of a full line, the cursor stays on its last column
+	return min(segs[i].X+col-segs[i].Col, width-1), p.lineOf(cur.Y, i, width)
+}
+
+// the rune of segment s that is shown at display column col (as the row is laid out unwrapped)
+//
+// a column past the end of the segment is its last character, unless the segment ends the row
+func indexInSegment(row buffer.BufRow, s buffer.Segment, col int) int {
+	x := row.IndexOfCol(col)
+	if x >= s.End && s.End < len(row) && s.End > s.Start {
+		return row.PrevBoundary(s.End)
+	}
+	return x
+}
+
+// the buffer position of the text shown at x, y on the screen, the last row below the end of
+// the buffer
+func (p *Pane) PositionAt(x int, y int, width int) (bx int, by int) {
+	rows := p.Buf.Rows
+	if len(rows) == 0 {
+		return 0, 0
+	}
+	x = max(x, 0)
+	if !p.Wrap {
+		by = min(p.RowOffset+max(y, 0), len(rows)-1)
+		return rows[by].IndexOfCol(p.ColOffset + x), by
+	}
+	seg := p.SegOffset
+	for by = p.RowOffset; by < len(rows); by++ {
+		segs := p.Segments(by, width)
+		for ; seg < len(segs); seg++ {
+			if y <= 0 {
+				s := segs[seg]
+				return indexInSegment(rows[by], s, s.Col+max(x-s.X, 0)), by
+			}
+			y--
+		}
+		seg = 0
+	}
+	last := len(rows) - 1
+	s := p.Segments(last, width)
+	return indexInSegment(rows[last], s[len(s)-1], s[len(s)-1].Col+x), last
+}
+
+// move the cursor delta screen lines down (up when negative), at the same column on the screen
+//
+// the same as moving by rows when the pane does not wrap. p is the active pane, the cursor is
+// moved through its buffer
+func (p *Pane) moveScreenLine(delta int, width int) bool {
+	buf, cur := p.Buf, p.Cursor()
+	if cur.Y >= len(buf.Rows) {
+		return false
+	}
+	y := cur.Y
+	segs := p.Segments(y, width)
+	i := buffer.SegmentAt(segs, cur.X)
+	col := buf.Rows[y].DisplayCol(cur.X) - segs[i].Col + segs[i].X
+	for ; delta != 0; delta -= sign(delta) {
+		i += sign(delta)
+		switch {
+		case i < 0:
+			if y == 0 {
+				return false
+			}
+			y--
+			segs = p.Segments(y, width)
+			i = len(segs) - 1
+		case i >= len(segs):
+			if y == len(buf.Rows)-1 {
+				return false
+			}
+			y++
+			segs = p.Segments(y, width)
+			i = 0
+		}
+	}
+	s := segs[i]
+	buf.SetCursor(indexInSegment(buf.Rows[y], s, s.Col+max(col-s.X, 0)), y)
+	return true
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	return 1
+}
+
+// the segment of the cursor's row the cursor is on, and the cursor's row
+func (p *Pane) cursorSegment(width int) (buffer.Segment, buffer.BufRow, bool) {
+	buf, cur := p.Buf, p.Cursor()
+	if cur.Y >= len(buf.Rows) {
+		return buffer.Segment{}, nil, false
+	}
+	row := buf.Rows[cur.Y]
+	if !p.Wrap {
+		// the part of the row that is in view
+		start := row.IndexOfCol(p.ColOffset)
+		return buffer.Segment{Start: start, End: row.IndexOfCol(p.ColOffset + width), Col: row.DisplayCol(start)}, row, true
+	}
+	segs := p.Segments(cur.Y, width)
+	return segs[buffer.SegmentAt(segs, cur.X)], row, true
+}
+
+// the active pane and the width of its text, for the screen line motions
+func (e *Editor) activeText() (*Pane, int, error) {
+	if e.Active == nil || e.Active.Pane == nil {
+		return nil, 0, ErrFailedMotion
+	}
+	return e.Active.Pane, e.Active.TextCols(), nil
+}
+
+// move the cursor down a screen line (gj)
+type ScreenLineDown struct{}
+
+func (a ScreenLineDown) String() string { return "ScreenLineDown" }
+func (a ScreenLineDown) Apply(e *Editor) error {
+	p, width, err := e.activeText()
+	if err != nil {
+		return err
+	}
+	return motion(p.moveScreenLine(1, width))
+}
+
+// move the cursor up a screen line (gk)
+type ScreenLineUp struct{}
+
+func (a ScreenLineUp) String() string { return "ScreenLineUp" }
+func (a ScreenLineUp) Apply(e *Editor) error {
+	p, width, err := e.activeText()
+	if err != nil {
+		return err
+	}
+	return motion(p.moveScreenLine(-1, width))
+}
+
+// move the cursor to the first character of its screen line (g0)
+type ScreenLineStart struct{}
+
+func (a ScreenLineStart) String() string { return "ScreenLineStart" }
+func (a ScreenLineStart) Apply(e *Editor) error {
+	p, width, err := e.activeText()
+	if err != nil {
+		return err
+	}
+	s, _, ok := p.cursorSegment(width)
+	if !ok {
+		return ErrFailedMotion
+	}
+	p.Buf.SetCursor(s.Start, p.Cursor().Y)
+	return nil
+}
+
+// move the cursor to the last character of its screen line (g$)
+type ScreenLineEnd struct{}
+
+func (a ScreenLineEnd) String() string { return "ScreenLineEnd" }
+func (a ScreenLineEnd) Apply(e *Editor) error {
+	p, width, err := e.activeText()
+	if err != nil {
+		return err
+	}
+	s, row, ok := p.cursorSegment(width)
+	if !ok {
+		return ErrFailedMotion
+	}
+	x := s.Start
+	if s.End > s.Start {
+		x = row.PrevBoundary(s.End)
+	}
+	p.Buf.SetCursor(x, p.Cursor().Y)
+	return nil
+}
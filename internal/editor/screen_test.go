@@ -0,0 +1,116 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+// a pane 10 columns wide and 4 rows high that wraps, and its buffer
+func wrapEditor(t *testing.T, rows ...string) *Editor {
+	t.Helper()
+	e := newTestEditor(rows...)
+	e.Root.Resize(Rect{Rows: 5, Cols: 10})
+	if err := e.Execute("set wrap"); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestScreenLineMotions(t *testing.T) {
+	e := wrapEditor(t, "0123456789abcdefghijklm", "short")
+	buf := e.BM.Current.Buf
+	tests := []struct {
+		keys []keyboard.Key
+		x, y int
+	}{
+		{[]keyboard.Key{'l', 'l', 'g', 'j'}, 12, 0},
+		{[]keyboard.Key{'g', '$'}, 19, 0},
+		{[]keyboard.Key{'g', '0'}, 10, 0},
+		{[]keyboard.Key{'g', 'j', 'g', 'j'}, 0, 1},
+		{[]keyboard.Key{'g', 'k'}, 20, 0},
+		{[]keyboard.Key{'g', 'k', 'g', 'k', 'g', 'k'}, 0, 0},
+	}
+	for _, tt := range tests {
+		typeKeys(t, e, tt.keys...)
+		if buf.X() != tt.x || buf.Y() != tt.y {
+			t.Errorf("after %q: cursor = %d,%d, want %d,%d", keyboard.OrderedKeyList(tt.keys).Collapse(), buf.X(), buf.Y(), tt.x, tt.y)
+		}
+	}
+	// at the top gk has nowhere to go
+	if err := (ScreenLineUp{}).Apply(e); err == nil {
+		t.Error("gk on the first screen line moved")
+	}
+}
+
+func TestScreenLineMotions_NoWrap(t *testing.T) {
+	e := newTestEditor("0123456789abcdefghijklm", "short")
+	e.Root.Resize(Rect{Rows: 4, Cols: 10})
+	buf := e.BM.Current.Buf
+	typeKeys(t, e, 'g', '$')
+	if buf.X() != 9 {
+		t.Errorf("g$ went to %d, want the last column in view", buf.X())
+	}
+	// like j, past the end of a shorter row
+	typeKeys(t, e, 'g', 'j')
+	if buf.X() != 5 || buf.Y() != 1 {
+		t.Errorf("gj: cursor = %d,%d, want 5,1", buf.X(), buf.Y())
+	}
+}
+
+func TestPane_ScrollWrapped(t *testing.T) {
+	long := make([]rune, 50)
+	for i := range long {
+		long[i] = 'a' + rune(i%26)
+	}
+	e := wrapEditor(t, "top", string(long), "end")
+	p, buf := e.Active.Pane, e.BM.Current.Buf
+	// 3 rows of text, the long row is 5 lines
+	buf.SetCursor(45, 1)
+	p.Scroll(3, 10)
+	if p.RowOffset != 1 || p.SegOffset != 2 {
+		t.Errorf("offset = %d,%d, want the long row from its third line", p.RowOffset, p.SegOffset)
+	}
+	if x, y := p.CursorAt(10); x != 5 || y != 2 {
+		t.Errorf("cursor on the screen at %d,%d, want 5,2", x, y)
+	}
+	buf.SetCursor(0, 2)
+	p.Scroll(3, 10)
+	if p.RowOffset != 1 || p.SegOffset != 3 {
+		t.Errorf("offset = %d,%d, want the last two lines of the long row above the end", p.RowOffset, p.SegOffset)
+	}
+	buf.SetCursor(0, 0)
+	p.Scroll(3, 10)
+	if p.RowOffset != 0 || p.SegOffset != 0 {
+		t.Errorf("offset = %d,%d, want the top", p.RowOffset, p.SegOffset)
+	}
+}
+
+func TestPane_ScrollWrappedWithoutRows(t *testing.T) {
+	e := wrapEditor(t, "top", "0123456789abcdefghijklm", "end")
+	p, buf := e.Active.Pane, e.BM.Current.Buf
+	// a pane of one row has just its status line
+	buf.SetCursor(0, 2)
+	p.Scroll(0, 10)
+	if p.RowOffset != 0 || p.SegOffset != 0 {
+		t.Errorf("offset = %d,%d, want it left alone", p.RowOffset, p.SegOffset)
+	}
+	// the rows under the offset were deleted
+	p.RowOffset, p.SegOffset = 5, 0
+	p.Scroll(1, 10)
+	if p.RowOffset != 2 {
+		t.Errorf("offset = %d, want the last row", p.RowOffset)
+	}
+}
+
+func TestMouse_ClickWrapped(t *testing.T) {
+	e := wrapEditor(t, "0123456789abcdefghijklm", "short")
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 3, 1)
+	if buf := e.BM.Current.Buf; buf.X() != 13 || buf.Y() != 0 {
+		t.Errorf("cursor = %d,%d, want 13,0", buf.X(), buf.Y())
+	}
+	click(t, e, keyboard.MousePress, keyboard.MouseLeft, 1, 3)
+	if buf := e.BM.Current.Buf; buf.X() != 1 || buf.Y() != 1 {
+		t.Errorf("cursor = %d,%d, want 1,1", buf.X(), buf.Y())
+	}
+}
@@ -0,0 +1,39 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/theme"
+)
+
+func defaultTheme() *theme.Theme {
+	t, _ := theme.Get(theme.Default)
+	return t
+}
+
+// the group the signs of each source are drawn in (see buffer.Sign), the signs of other sources
+// are drawn like the sign column
+var SignGroups = map[string]theme.Group{
+	auditSign.Source: theme.Suspicious,
+}
+
+// the theme to draw with
+func (e *Editor) Theme() *theme.Theme {
+	return e.theme
+}
+
+// switch to the theme named in args, without one show the theme and the others there are
+func colorScheme(e *Editor, r lineRange, args string) error {
+	name := strings.TrimSpace(args)
+	if name == "" {
+		e.message = fmt.Sprintf("%s (%s)", e.theme.Name, strings.Join(theme.Names(), " "))
+		return nil
+	}
+	t, ok := theme.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown color scheme: %s", name)
+	}
+	e.theme = t
+	return nil
+}
@@ -0,0 +1,59 @@
+package editor
+
+import (
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/mode"
+)
+
+// the text between two cursors, both ends are included
+type Selection struct {
+	Start buffer.Cursor
+	End   buffer.Cursor
+}
+
+// the current visual selection, ordered so that Start comes first
+//
+// returns nil when not in visual mode
+func (e *Editor) Selection() *Selection {
+	if e.m.Current() != mode.Visual {
+		return nil
+	}
+	buf := e.BM.Current.Buf
+	a := e.visual
+	b := buffer.Cursor{X: buf.X(), Y: buf.Y()}
+	if b.Y < a.Y || (b.Y == a.Y && b.X < a.X) {
+		a, b = b, a
+	}
+	return &Selection{Start: a, End: b}
+}
+
+// true if the rune at x, y is selected
+func (s *Selection) Contains(x, y int) bool {
+	if y < s.Start.Y || y > s.End.Y {
+		return false
+	}
+	if y == s.Start.Y && x < s.Start.X {
+		return false
+	}
+	if y == s.End.Y && x > s.End.X {
+		return false
+	}
+	return true
+}
+
+// the selected text as a (charwise) register
+func (s *Selection) Register(buf *buffer.Buffer) Register {
+	reg := Register{}
+	for y := s.Start.Y; y <= s.End.Y && y < len(buf.Rows); y++ {
+		row := buf.Rows[y]
+		start, end := 0, len(row)
+		if y == s.Start.Y {
+			start = min(s.Start.X, len(row))
+		}
+		if y == s.End.Y {
+			end = min(s.End.X+1, len(row))
+		}
+		reg.Rows = append(reg.Rows, append([]rune(nil), row[start:end]...))
+	}
+	return reg
+}
@@ -0,0 +1,243 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// the commands on the layout of panes, bound after Ctrl-W
+
+var errLastPane = errors.New("cannot close the last pane")
+
+// show the error as a message and fail the action, rather than stop the editor
+func (e *Editor) fail(err error) error {
+	e.message = err.Error()
+	return fmt.Errorf("%w: %w", ErrFailed, err)
+}
+
+// show the whole layout again if a pane is zoomed
+//
+// the commands that change the layout work on all of it, so they put it back first
+func (e *Editor) unzoom() {
+	if e.zoomed == nil {
+		return
+	}
+	r := e.Root.Rect
+	e.Root, e.zoomed = e.zoomed, nil
+	e.Root.Resize(r)
+}
+
+// whether the active pane is zoomed to fill the screen
+func (e *Editor) Zoomed() bool {
+	return e.zoomed != nil
+}
+
+// where the cursor of the active pane is on the screen
+func (e *Editor) cursorOnScreen() (x int, y int) {
+	n := e.Active
+	x, y = n.Pane.CursorAt(n.TextCols())
+	return n.Rect.X + n.Pane.GutterWidth(n.Rect.Cols) + x, n.Rect.Y + y
+}
+
+// take pane n out of the layout and focus the pane next to it
+func (e *Editor) closePane(n *SplitNode) error {
+	e.unzoom()
+	root, next := e.Root.Remove(n)
+	if next == nil {
+		return errLastPane
+	}
+	e.Root = root
+	n.Pane.Buf.CloseView(n.Pane.View)
+	e.Focus(next)
+	return nil
+}
+
+// close every pane but the active one
+func (e *Editor) onlyPane() {
+	e.unzoom()
+	e.Root.Leaves(func(l *SplitNode) {
+		if l != e.Active {
+			l.Pane.Buf.CloseView(l.Pane.View)
+		}
+	})
+	e.Active.Resize(e.Root.Rect)
+	e.Root = e.Active
+}
+
+// move the cursor to the pane on a side of the active one (Ctrl-W h/j/k/l)
+type FocusPane struct{ side Side }
+
+func (a FocusPane) String() string { return fmt.Sprintf("focus pane %d", a.side) }
+func (a FocusPane) Apply(e *Editor) error {
+	e.unzoom()
+	x, y := e.cursorOnScreen()
+	at := x
+	if a.side == LeftOf || a.side == RightOf {
+		at = y
+	}
+	n := e.Root.Neighbor(e.Active, a.side, at)
+	if n == nil {
+		return ErrFailed
+	}
+	e.Focus(n)
+	return nil
+}
+
+// move the cursor to the next pane, after the last one to the first (Ctrl-W w)
+type FocusNextPane struct{ back bool }
+
+func (a FocusNextPane) String() string { return fmt.Sprintf("focus next pane (back: %v)", a.back) }
+func (a FocusNextPane) Apply(e *Editor) error {
+	e.unzoom()
+	var leaves []*SplitNode
+	e.Root.Leaves(func(l *SplitNode) { leaves = append(leaves, l) })
+	i := slices.Index(leaves, e.Active)
+	if a.back {
+		i += len(leaves) - 2
+	}
+	e.Focus(leaves[(i+1)%len(leaves)])
+	return nil
+}
+
+// close the active pane (Ctrl-W c), the last pane cannot be closed unless quit is set
+// and the editor exits (Ctrl-W q), like :q it does not exit with changes that have not been written
+type ClosePane struct{ quit bool }
+
+func (a ClosePane) String() string { return fmt.Sprintf("close pane (quit: %v)", a.quit) }
+func (a ClosePane) Apply(e *Editor) error {
+	err := e.closePane(e.Active)
+	if errors.Is(err, errLastPane) && a.quit {
+		if err := e.unwritten(); err != nil {
+			return e.fail(err)
+		}
+		return ErrExit
+	}
+	if err != nil {
+		return e.fail(err)
+	}
+	return nil
+}
+
+// close every pane but the active one (Ctrl-W o)
+type OnlyPane struct{}
+
+func (a OnlyPane) String() string        { return "only pane" }
+func (a OnlyPane) Apply(e *Editor) error { e.onlyPane(); return nil }
+
+// make the active pane a row taller, a column wider for Vertical, or smaller when by is
+// negative (Ctrl-W + - > <)
+type GrowPane struct {
+	dir SplitDirection
+	by  int
+}
+
+func (a GrowPane) String() string { return fmt.Sprintf("grow pane %d by %d", a.dir, a.by) }
+func (a GrowPane) Apply(e *Editor) error {
+	e.unzoom()
+	e.Root.Grow(e.Active, a.dir, a.by)
+	return nil
+}
+
+// give all the panes the same size (Ctrl-W =)
+type EqualizePanes struct{}
+
+func (a EqualizePanes) String() string { return "equalize panes" }
+func (a EqualizePanes) Apply(e *Editor) error {
+	e.unzoom()
+	e.Root.Equalize()
+	return nil
+}
+
+// the leaves of the row of the active pane, it cannot be changed when any of it is split the other way
+func (e *Editor) activeRow() ([]*SplitNode, error) {
+	row := e.Root.row(e.Active)
+	for _, n := range row {
+		if n.Pane == nil {
+			return nil, fmt.Errorf("cannot move panes next to a split")
+		}
+	}
+	return row, nil
+}
+
+// the active pane and the next one in its row change places, the last one changes with
+// the one before it (Ctrl-W x)
+type ExchangePane struct{}
+
+func (a ExchangePane) String() string { return "exchange pane" }
+func (a ExchangePane) Apply(e *Editor) error {
+	e.unzoom()
+	row := e.Root.row(e.Active)
+	i := slices.Index(row, e.Active)
+	j := i + 1
+	if j == len(row) {
+		j = i - 1
+	}
+	if j < 0 {
+		return ErrFailed
+	}
+	if row[j].Pane == nil {
+		return e.fail(fmt.Errorf("cannot exchange a pane with a split"))
+	}
+	row[i].Pane, row[j].Pane = row[j].Pane, row[i].Pane
+	e.Focus(row[j])
+	return nil
+}
+
+// move the panes of the active pane's row one place down or right, the last one goes
+// first (Ctrl-W r), or up or left when back is set (Ctrl-W R)
+type RotatePanes struct{ back bool }
+
+func (a RotatePanes) String() string { return fmt.Sprintf("rotate panes (back: %v)", a.back) }
+func (a RotatePanes) Apply(e *Editor) error {
+	e.unzoom()
+	row, err := e.activeRow()
+	if err != nil {
+		return e.fail(err)
+	}
+	panes := make([]*Pane, len(row))
+	for i, n := range row {
+		panes[i] = n.Pane
+	}
+	active := e.Active.Pane
+	for i, n := range row {
+		if a.back {
+			n.Pane = panes[(i+1)%len(panes)]
+		} else {
+			n.Pane = panes[(i+len(panes)-1)%len(panes)]
+		}
+		if n.Pane == active {
+			e.Focus(n)
+		}
+	}
+	return nil
+}
+
+// move the active pane to an edge of the screen, along all of it (Ctrl-W H/J/K/L)
+type MovePane struct{ side Side }
+
+func (a MovePane) String() string { return fmt.Sprintf("move pane %d", a.side) }
+func (a MovePane) Apply(e *Editor) error {
+	e.unzoom()
+	e.Root = e.Root.MoveToEdge(e.Active, a.side)
+	return nil
+}
+
+// let the active pane fill the screen, until the layout is changed or the pane is zoomed
+// again (Ctrl-W z)
+type ToggleZoom struct{}
+
+func (a ToggleZoom) String() string { return "toggle zoom" }
+func (a ToggleZoom) Apply(e *Editor) error {
+	if e.zoomed != nil {
+		e.unzoom()
+		return nil
+	}
+	if e.Root == e.Active {
+		return ErrFailed
+	}
+	e.zoomed = e.Root
+	e.Active.Resize(e.Root.Rect)
+	e.Root = e.Active
+	return nil
+}
@@ -0,0 +1,197 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/keyboard"
+)
+
+// the editor's layout on a screen of 20 by 41, as the renderer would set it
+func layOut(e *Editor) {
+	e.Root.Resize(Rect{Rows: 20, Cols: 41})
+}
+
+// n panes of the current buffer next to each other in dir, the first is active
+func splitInto(e *Editor, dir SplitDirection, n int) {
+	panes := make([]*Pane, n)
+	for i := range panes {
+		panes[i] = &Pane{Buf: e.BM.Current.Buf}
+	}
+	e.Root = NewLayout(dir, panes...)
+	layOut(e)
+	e.Focus(e.Root.firstLeaf())
+}
+
+// the panes of the layout that are marked active
+func activePanes(e *Editor) []*SplitNode {
+	var active []*SplitNode
+	e.Root.Leaves(func(l *SplitNode) {
+		if l.Pane.Active {
+			active = append(active, l)
+		}
+	})
+	return active
+}
+
+func assertActive(t *testing.T, e *Editor, want *SplitNode) {
+	t.Helper()
+	if e.Active != want {
+		t.Errorf("the wrong pane is active")
+	}
+	if active := activePanes(e); len(active) != 1 || active[0] != e.Active {
+		t.Errorf("%d panes are marked active, want just the editor's", len(active))
+	}
+}
+
+func TestWindow_FocusByGeometry(t *testing.T) {
+	e := newTestEditor("zero", "one", "two")
+	typeKeys(t, e, 'v')
+	layOut(e)
+	typeKeys(t, e, keyboard.CtrlW, 'l', 's')
+	layOut(e)
+	left, top, bottom := e.Root.First, e.Root.Second.First, e.Root.Second.Second
+	assertActive(t, e, top)
+
+	typeKeys(t, e, keyboard.CtrlW, 'j')
+	assertActive(t, e, bottom)
+	typeKeys(t, e, keyboard.CtrlW, 'h')
+	assertActive(t, e, left)
+	// there is nothing further left
+	typeKeys(t, e, keyboard.CtrlW, 'h')
+	assertActive(t, e, left)
+	typeKeys(t, e, keyboard.CtrlW, 'w', keyboard.CtrlW, 'w')
+	assertActive(t, e, bottom)
+	typeKeys(t, e, keyboard.CtrlW, 'W')
+	assertActive(t, e, top)
+}
+
+func TestWindow_Close(t *testing.T) {
+	e := newTestEditor("zero", "one", "two")
+	buf := e.BM.Current.Buf
+	typeKeys(t, e, 'v', 's')
+	layOut(e)
+	left, right := e.Root.First, e.Root.Second
+
+	typeKeys(t, e, 'j', keyboard.CtrlW, 'c')
+	if e.Root.First != left.Second {
+		t.Fatalf("the split of the closed pane did not collapse")
+	}
+	assertActive(t, e, left.Second)
+	if buf.Y() != 0 {
+		t.Errorf("the cursor of the closed pane is still used")
+	}
+	if err := e.Execute("close"); err != nil {
+		t.Fatal(err)
+	}
+	assertActive(t, e, right)
+	if e.Root != right {
+		t.Errorf("the last pane is not the root")
+	}
+	typeKeys(t, e, keyboard.CtrlW, 'c')
+	if e.Message() != errLastPane.Error() {
+		t.Errorf("message = %q, want %q", e.Message(), errLastPane)
+	}
+	if err := e.handleKey(keyboard.CtrlW); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.handleKey('q'); err != ErrExit {
+		t.Errorf("Ctrl-W q on the last pane = %v, want %v", err, ErrExit)
+	}
+}
+
+func TestWindow_Only(t *testing.T) {
+	e := newTestEditor("zero", "one")
+	typeKeys(t, e, 'v', 's', 'j')
+	layOut(e)
+	active := e.Active
+	typeKeys(t, e, keyboard.CtrlW, 'o')
+	if e.Root != active || active.Pane == nil {
+		t.Fatal("the active pane is not the only one")
+	}
+	assertActive(t, e, active)
+	if e.BM.Current.Buf.Y() != 1 {
+		t.Errorf("the pane lost its cursor")
+	}
+}
+
+func TestWindow_ExchangeAndRotate(t *testing.T) {
+	e := newTestEditor("zero")
+	splitInto(e, Vertical, 3)
+	a, b, c := e.Root.First, e.Root.Second.First, e.Root.Second.Second
+	pa, pb, pc := a.Pane, b.Pane, c.Pane
+
+	typeKeys(t, e, keyboard.CtrlW, 'x')
+	if a.Pane != pb || b.Pane != pa {
+		t.Fatal("the panes did not change places")
+	}
+	assertActive(t, e, b)
+
+	typeKeys(t, e, keyboard.CtrlW, 'r')
+	if a.Pane != pc || b.Pane != pb || c.Pane != pa {
+		t.Errorf("the panes did not rotate")
+	}
+	assertActive(t, e, c)
+	typeKeys(t, e, keyboard.CtrlW, 'R')
+	if a.Pane != pb || b.Pane != pa || c.Pane != pc {
+		t.Errorf("the panes did not rotate back")
+	}
+	assertActive(t, e, b)
+
+	// a row with a split in it cannot rotate
+	typeKeys(t, e, 's')
+	layOut(e)
+	typeKeys(t, e, keyboard.CtrlW, 'h', keyboard.CtrlW, 'r')
+	if e.Message() == "" {
+		t.Errorf("no message for a row that cannot rotate")
+	}
+}
+
+func TestWindow_MoveAndResize(t *testing.T) {
+	e := newTestEditor("zero")
+	splitInto(e, Vertical, 3)
+	typeKeys(t, e, keyboard.CtrlW, 'l')
+	moved := e.Active
+	typeKeys(t, e, keyboard.CtrlW, 'J')
+	if e.Root.Dir != Horizontal || e.Root.Second != moved {
+		t.Fatal("the pane did not move to the bottom")
+	}
+	if moved.Rect.Cols != 41 {
+		t.Errorf("the moved pane is %d wide, want the whole width", moved.Rect.Cols)
+	}
+	assertActive(t, e, moved)
+
+	rows := moved.Rect.Rows
+	typeKeys(t, e, '3', keyboard.CtrlW, '+')
+	if moved.Rect.Rows != rows+3 {
+		t.Errorf("rows = %d after 3 Ctrl-W +, want %d", moved.Rect.Rows, rows+3)
+	}
+	typeKeys(t, e, keyboard.CtrlW, '=')
+	if moved.Rect.Rows != rows {
+		t.Errorf("rows = %d after Ctrl-W =, want %d", moved.Rect.Rows, rows)
+	}
+}
+
+func TestWindow_Zoom(t *testing.T) {
+	e := newTestEditor("zero")
+	splitInto(e, Vertical, 2)
+	root, active := e.Root, e.Active
+
+	typeKeys(t, e, keyboard.CtrlW, 'z')
+	if e.Root != active || !e.Zoomed() {
+		t.Fatal("the pane is not zoomed")
+	}
+	if active.Rect != (Rect{Rows: 20, Cols: 41}) {
+		t.Errorf("the zoomed pane is %+v, want the whole screen", active.Rect)
+	}
+	typeKeys(t, e, keyboard.CtrlW, 'z')
+	if e.Root != root || e.Zoomed() {
+		t.Fatal("the layout did not come back")
+	}
+
+	// moving to another pane shows the layout again
+	typeKeys(t, e, keyboard.CtrlW, 'z', keyboard.CtrlW, 'l')
+	if e.Root != root || e.Zoomed() {
+		t.Errorf("the layout did not come back")
+	}
+	assertActive(t, e, root.Second)
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"unicode/utf8"
@@ -23,35 +24,50 @@ func BytesToRunes(b []byte) []rune {
 }
 
 // returns the contents, a bool telling you if the file is writeable
-func ReadFile(path string) ([][]rune, bool, FileType, error) {
+func ReadFile(path string) ([][]rune, bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, false, Unknown, err
+		return nil, false, err
 	}
 	defer f.Close()
 
 	info, err := f.Stat()
 	if err != nil {
-		return nil, false, Unknown, err
+		return nil, false, err
 	}
 	mode := info.Mode()
 	writeable := mode&0200 != 0
 
-	scanner := bufio.NewScanner(f)
-	numScans := 0
+	contents, err := ReadLines(f)
+	if err != nil {
+		return nil, false, err
+	}
+	return contents, writeable, nil
+}
 
+// read the lines of r, without their line endings
+//
+// there is always at least one (possibly empty) line
+func ReadLines(r io.Reader) ([][]rune, error) {
+	br := bufio.NewReader(r)
 	contents := [][]rune{}
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		line = bytes.TrimRight(line, "\r\n")
-		contents = append(contents, BytesToRunes(line))
-		numScans++
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+			contents = append(contents, BytesToRunes(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
-	if numScans == 0 {
+	if len(contents) == 0 {
 		contents = append(contents, []rune{})
 	}
-	t := DetermineFileType(path)
-	return contents, writeable, t, nil
+	return contents, nil
 }
 
 func Save(filename string, buf []byte) (int, error) {
@@ -62,11 +78,12 @@ func Save(filename string, buf []byte) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("unable to open file: %w", err)
 	}
+	defer file.Close()
 	n, err := file.Write(buf)
 	if err != nil {
 		return 0, fmt.Errorf("unable to write file: %w", err)
 	}
-	return n, nil
+	return n, file.Close()
 }
 
 // check if two paths point to the same place
@@ -0,0 +1,119 @@
+// display widths and grapheme clusters (what a user sees as one character)
+package grapheme
+
+import (
+	"sort"
+	"unicode"
+)
+
+const (
+	zwj = 0x200D
+	// variation selector 16 asks for the emoji presentation, which is wide
+	vs16 = 0xFE0F
+)
+
+// the number of columns r takes up in a terminal: 0, 1 or 2
+//
+// control characters are 0, how they are shown is up to the caller
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return 0
+	case r < 0x300:
+		// latin, without combining marks. the soft hyphen is shown
+		if r >= 0x80 && r < 0xA0 {
+			return 0
+		}
+		return 1
+	case zeroWidth(r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// combining marks, format characters (e.g. zero width joiner) and the hangul vowels and
+// final consonants that join the leading consonant before them
+func zeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		(r >= 0x1160 && r <= 0x11FF) || (r >= 0xD7B0 && r <= 0xD7FF) || r == 0x200B
+}
+
+func isWide(r rune) bool {
+	i := sort.Search(len(wide), func(i int) bool { return wide[i][1] >= r })
+	return i < len(wide) && wide[i][0] <= r
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7F || (r >= 0x80 && r < 0xA0)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// skin tone modifiers
+func isModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// joins the character before it into one cluster
+func extends(r rune) bool {
+	return !isControl(r) && (zeroWidth(r) || isModifier(r) || unicode.Is(unicode.Mc, r))
+}
+
+// the length in runes and the width in columns of the grapheme cluster at the start of rs
+//
+// a simplified version of the rules in UAX #29: combining marks and other extenders join the
+// character before them, a zero width joiner joins the next character, regional indicators
+// come in pairs (flags), and \r\n is one cluster. a control character is a cluster on its own
+// with width 0. a mark with nothing to combine with takes a column, it is shown on a space
+func Next(rs []rune) (n int, width int) {
+	if len(rs) == 0 {
+		return 0, 0
+	}
+	first := rs[0]
+	if first == '\r' && len(rs) > 1 && rs[1] == '\n' {
+		return 2, 0
+	}
+	if isControl(first) {
+		return 1, 0
+	}
+	n, width = 1, max(RuneWidth(first), 1)
+	if isRegionalIndicator(first) {
+		if len(rs) > 1 && isRegionalIndicator(rs[1]) {
+			n++
+		}
+		width = 2
+	}
+	for n < len(rs) {
+		r := rs[n]
+		switch {
+		case r == zwj && n+1 < len(rs) && !isControl(rs[n+1]):
+			n += 2
+		case r == vs16:
+			n++
+			if width > 0 {
+				width = 2
+			}
+		case extends(r):
+			n++
+		default:
+			return n, width
+		}
+	}
+	return n, width
+}
+
+// the number of columns s takes up, control characters take none
+func Width(s string) int {
+	rs := []rune(s)
+	w := 0
+	for len(rs) > 0 {
+		n, cw := Next(rs)
+		w += cw
+		rs = rs[n:]
+	}
+	return w
+}
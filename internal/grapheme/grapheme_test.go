@@ -0,0 +1,67 @@
+package grapheme
+
+import "testing"
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'é', 1},
+		{'\t', 0},
+		{0x7F, 0},
+		{0x0301, 0}, // combining acute accent
+		{0x200B, 0}, // zero width space
+		{0x200D, 0}, // zero width joiner
+		{'世', 2},
+		{'ｱ', 1}, // halfwidth katakana
+		{'Ａ', 2}, // fullwidth latin
+		{'한', 2},
+		{0x1160, 0}, // hangul vowel, joins the consonant before it
+		{'👍', 2},
+		{'Δ', 1},
+		{0x20000, 2},
+	}
+	for _, tt := range tests {
+		if got := RuneWidth(tt.r); got != tt.want {
+			t.Errorf("RuneWidth(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		n     int
+		width int
+	}{
+		{name: "ascii", s: "ab", n: 1, width: 1},
+		{name: "combining marks", s: "é̂x", n: 3, width: 1},
+		{name: "wide", s: "世界", n: 1, width: 2},
+		{name: "crlf", s: "\r\nx", n: 2, width: 0},
+		{name: "control", s: "\x1bx", n: 1, width: 0},
+		{name: "skin tone", s: "👍🏽x", n: 2, width: 2},
+		{name: "zwj sequence", s: "👨‍👩‍👧x", n: 5, width: 2},
+		{name: "flag", s: "🇳🇿🇦🇺", n: 2, width: 2},
+		{name: "emoji presentation", s: "☺️x", n: 2, width: 2},
+		{name: "hangul jamo", s: "각x", n: 3, width: 2},
+		{name: "lone mark", s: "́x", n: 1, width: 1},
+		{name: "empty", s: "", n: 0, width: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, width := Next([]rune(tt.s))
+			if n != tt.n || width != tt.width {
+				t.Errorf("Next(%q) = %d, %d, want %d, %d", tt.s, n, width, tt.n, tt.width)
+			}
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	if got := Width("a世é👍🏽"); got != 6 {
+		t.Errorf("width = %d, want 6", got)
+	}
+}
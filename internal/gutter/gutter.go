@@ -1,16 +1,105 @@
 package gutter
 
+import (
+	"strconv"
+
+	"github.com/jcocozza/jte/internal/buffer"
+)
+
+// the columns the sign column takes up
+const SignWidth = 2
+
+// the fewest columns the line numbers take up, not counting the space after them
+const minNumberWidth = 3
+
+// how the lines are numbered
+type NumberMode int
+
+const (
+	NoNumbers NumberMode = iota
+	// the line's number
+	Absolute
+	// the distance from the cursor's line
+	Relative
+	// relative, with the cursor's line showing its own number
+	Hybrid
+)
+
+// what the gutter shows next to a line
 type GutterRow struct {
+	// the number shown, -1 for none
 	Num int
-	Content []rune
+	// the line is the cursor's
+	Current bool
+	// the sign shown, its Text is empty for none
+	Sign buffer.Sign
 }
 
+// the settings of a pane's gutter, the zero Gutter shows nothing
 type Gutter struct {
-	Rows []GutterRow
+	Number         bool
+	RelativeNumber bool
+	// show the sign column when the buffer has no signs
+	SignColumn bool
 }
 
+// the gutter panes start with
 func NewGutter() *Gutter {
-	return &Gutter{
-		Rows: []GutterRow{},
+	return &Gutter{Number: true}
+}
+
+func (g *Gutter) Mode() NumberMode {
+	switch {
+	case g.Number && g.RelativeNumber:
+		return Hybrid
+	case g.RelativeNumber:
+		return Relative
+	case g.Number:
+		return Absolute
+	}
+	return NoNumbers
+}
+
+// the columns the numbers of a buffer of lines lines take up, with the space after them
+//
+// it grows with the number of lines, so the numbers do not move as the cursor does
+func (g *Gutter) NumberWidth(lines int) int {
+	if g.Mode() == NoNumbers {
+		return 0
+	}
+	return max(len(strconv.Itoa(lines)), minNumberWidth) + 1
+}
+
+// the columns the gutter takes up next to a buffer of lines lines with signs
+func (g *Gutter) Width(lines int, signs *buffer.Signs) int {
+	w := g.NumberWidth(lines)
+	if g.SignColumn || signs.Len() > 0 {
+		w += SignWidth
+	}
+	return w
+}
+
+// what the gutter shows next to line y when the cursor is on line cursor
+func (g *Gutter) Row(y int, cursor int, signs *buffer.Signs) GutterRow {
+	r := GutterRow{Num: -1, Current: y == cursor}
+	switch g.Mode() {
+	case Absolute:
+		r.Num = y + 1
+	case Relative:
+		r.Num = abs(y - cursor)
+	case Hybrid:
+		r.Num = abs(y - cursor)
+		if r.Current {
+			r.Num = y + 1
+		}
+	}
+	r.Sign, _ = signs.At(y)
+	return r
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
+	return n
 }
@@ -0,0 +1,52 @@
+package gutter
+
+import (
+	"testing"
+
+	"github.com/jcocozza/jte/internal/buffer"
+)
+
+func TestGutter_Row(t *testing.T) {
+	tests := []struct {
+		g    Gutter
+		want []int
+	}{
+		{Gutter{}, []int{-1, -1, -1, -1}},
+		{Gutter{Number: true}, []int{1, 2, 3, 4}},
+		{Gutter{RelativeNumber: true}, []int{2, 1, 0, 1}},
+		{Gutter{Number: true, RelativeNumber: true}, []int{2, 1, 3, 1}},
+	}
+	for _, tt := range tests {
+		for y, want := range tt.want {
+			row := tt.g.Row(y, 2, &buffer.Signs{})
+			if row.Num != want || row.Current != (y == 2) {
+				t.Errorf("mode %d, line %d: %+v, want %d", tt.g.Mode(), y, row, want)
+			}
+		}
+	}
+}
+
+func TestGutter_Width(t *testing.T) {
+	g := &Gutter{Number: true}
+	var signs buffer.Signs
+	tests := []struct {
+		lines, want int
+	}{
+		{0, 4},
+		{999, 4},
+		{1000, 5},
+		{123456, 7},
+	}
+	for _, tt := range tests {
+		if got := g.Width(tt.lines, &signs); got != tt.want {
+			t.Errorf("%d lines: width %d, want %d", tt.lines, got, tt.want)
+		}
+	}
+	signs.Place(0, buffer.Sign{Source: "test", Text: "x"})
+	if got := g.Width(10, &signs); got != 4+SignWidth {
+		t.Errorf("width with signs %d, want %d", got, 4+SignWidth)
+	}
+	if got := (&Gutter{SignColumn: true}).Width(10, &buffer.Signs{}); got != SignWidth {
+		t.Errorf("width of the sign column %d, want %d", got, SignWidth)
+	}
+}
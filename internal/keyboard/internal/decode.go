@@ -0,0 +1,409 @@
+package internal
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// key modifiers
+//
+// the bits are the same as in the xterm and kitty modifier parameter (minus 1)
+type Modifier uint8
+
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
+	ModSuper
+	ModHyper
+	ModMeta
+
+	modMask = ModShift | ModAlt | ModCtrl | ModSuper | ModHyper | ModMeta
+)
+
+// the result of decoding the start of the input
+type decoded int
+
+const (
+	// a key was decoded
+	decodedKey decoded = iota
+	// a complete sequence was read, but it is not something we know about
+	decodedUnknown
+	// the input is the start of a sequence that is not complete yet
+	decodedIncomplete
+	// a reply from the terminal to a query
+	decodedReply
+)
+
+// decode the first key in buf
+//
+// returns the keypress and the number of bytes used.
+// when flush is set, an incomplete sequence is decoded as best we can;
+// this is how a lone ESC is told apart from the start of an escape sequence
+func decode(buf []byte, flush bool) (Keypress, int, decoded) {
+	if len(buf) == 0 {
+		return Keypress{}, 0, decodedIncomplete
+	}
+	if buf[0] == '\033' {
+		kp, n, res := decodeEscape(buf)
+		if res == decodedIncomplete && flush && !bytes.HasPrefix(buf, pasteStart) {
+			// what would be the start of a reply on its own is Alt+]
+			if len(buf) == 2 && buf[1] == ']' {
+				return Keypress{Unicode: ']', Mod: ModAlt}, 2, decodedKey
+			}
+			return Keypress{Key: ESC}, 1, decodedKey
+		}
+		return kp, n, res
+	}
+	return decodeChar(buf, flush)
+}
+
+// a single (non escape) character
+func decodeChar(buf []byte, flush bool) (Keypress, int, decoded) {
+	b := SpecialKey(buf[0])
+	if b <= Ctrl_UNDERSCORE || b == BACKSPACE_2 {
+		return Keypress{Key: b}, 1, decodedKey
+	}
+	if !utf8.FullRune(buf) {
+		if flush {
+			return Keypress{}, 1, decodedUnknown
+		}
+		return Keypress{}, 0, decodedIncomplete
+	}
+	r, size := utf8.DecodeRune(buf)
+	if r == utf8.RuneError {
+		return Keypress{}, size, decodedUnknown
+	}
+	return Keypress{Unicode: r}, size, decodedKey
+}
+
+// assumes that buf[0] = '\033'
+func decodeEscape(buf []byte) (Keypress, int, decoded) {
+	if len(buf) == 1 {
+		return Keypress{}, 0, decodedIncomplete
+	}
+	switch buf[1] {
+	case '[':
+		return decodeCSI(buf)
+	case 'O':
+		return decodeSS3(buf)
+	case ']':
+		return decodeOSC(buf)
+	case '\033':
+		// ESC ESC is just two escapes
+		return Keypress{Key: ESC}, 1, decodedKey
+	}
+	// anything else is Alt + the key
+	kp, n, res := decodeChar(buf[1:], false)
+	switch res {
+	case decodedIncomplete:
+		return kp, 0, res
+	case decodedUnknown:
+		return kp, n + 1, res
+	}
+	kp.Mod |= ModAlt
+	return kp, n + 1, decodedKey
+}
+
+// ESC O <final>
+func decodeSS3(buf []byte) (Keypress, int, decoded) {
+	if len(buf) < 3 {
+		return Keypress{}, 0, decodedIncomplete
+	}
+	if key, ok := finalKeys[buf[2]]; ok {
+		return Keypress{Key: key}, 3, decodedKey
+	}
+	return Keypress{}, 3, decodedUnknown
+}
+
+// ESC ] <text> BEL, or ESC ] <text> ESC \
+//
+// an operating system command, the terminal's reply to a query like the background color (OSC 11)
+func decodeOSC(buf []byte) (Keypress, int, decoded) {
+	for i := 2; i < len(buf); i++ {
+		switch buf[i] {
+		case '\a':
+			return Keypress{reply: ']', text: string(buf[2:i])}, i + 1, decodedReply
+		case '\033':
+			if i+1 == len(buf) {
+				return Keypress{}, 0, decodedIncomplete
+			}
+			if buf[i+1] == '\\' {
+				return Keypress{reply: ']', text: string(buf[2:i])}, i + 2, decodedReply
+			}
+			// cut off by another sequence
+			return Keypress{}, i, decodedUnknown
+		}
+	}
+	return Keypress{}, 0, decodedIncomplete
+}
+
+// keys that are identified by the final byte of a CSI or SS3 sequence
+var finalKeys = map[byte]SpecialKey{
+	'A': ARROW_UP,
+	'B': ARROW_DOWN,
+	'C': ARROW_RIGHT,
+	'D': ARROW_LEFT,
+	'F': END,
+	'H': HOME,
+	'P': F1,
+	'Q': F2,
+	'R': F3,
+	'S': F4,
+}
+
+// keys that are identified by the first parameter of a CSI ... ~ sequence
+var tildeKeys = map[int]SpecialKey{
+	1:  HOME,
+	2:  INSERT,
+	3:  DELETE,
+	4:  END,
+	5:  PAGE_UP,
+	6:  PAGE_DOWN,
+	7:  HOME,
+	8:  END,
+	11: F1,
+	12: F2,
+	13: F3,
+	14: F4,
+	15: F5,
+	17: F6,
+	18: F7,
+	19: F8,
+	20: F9,
+	21: F10,
+	23: F11,
+	24: F12,
+}
+
+// a parsed CSI sequence: ESC [ <params> <intermediates> <final>
+type csi struct {
+	// a private marker (e.g. '<' or '?') at the start of the parameters, 0 if none
+	marker byte
+	params [][]int
+	final  byte
+}
+
+// the first sub parameter of the i'th parameter, def if it is missing
+func (c csi) param(i int, def int) int {
+	if i >= len(c.params) || len(c.params[i]) == 0 || c.params[i][0] < 0 {
+		return def
+	}
+	return c.params[i][0]
+}
+
+// the modifiers in the second parameter
+//
+// the kitty protocol also reports caps lock and num lock here, those are dropped
+func (c csi) mod() Modifier {
+	m := c.param(1, 1) - 1
+	if m < 0 {
+		return 0
+	}
+	return Modifier(m) & modMask
+}
+
+// the event type in the second parameter: 1 press, 2 repeat, 3 release
+func (c csi) event() int {
+	if len(c.params) < 2 || len(c.params[1]) < 2 || c.params[1][1] < 0 {
+		return 1
+	}
+	return c.params[1][1]
+}
+
+// returns the sequence and its length, or a length of 0 if it is not complete
+func parseCSI(buf []byte) (csi, int, bool) {
+	i := 2
+	for i < len(buf) && buf[i] >= 0x30 && buf[i] <= 0x3F {
+		i++
+	}
+	paramEnd := i
+	for i < len(buf) && buf[i] >= 0x20 && buf[i] <= 0x2F {
+		i++
+	}
+	if i >= len(buf) {
+		return csi{}, 0, true
+	}
+	if buf[i] < 0x40 || buf[i] > 0x7E {
+		// not a valid sequence, drop what we have read so far
+		return csi{}, i, false
+	}
+	c := csi{final: buf[i]}
+	params := string(buf[2:paramEnd])
+	if len(params) > 0 && strings.ContainsRune("<=>?", rune(params[0])) {
+		c.marker = params[0]
+		params = params[1:]
+	}
+	if params != "" {
+		for _, p := range strings.Split(params, ";") {
+			sub := []int{}
+			for _, s := range strings.Split(p, ":") {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					n = -1
+				}
+				sub = append(sub, n)
+			}
+			c.params = append(c.params, sub)
+		}
+	}
+	return c, i + 1, true
+}
+
+// bracketed paste markers
+var (
+	pasteStart = []byte("\x1b[200~")
+	pasteEnd   = []byte("\x1b[201~")
+)
+
+// ESC [ 200 ~ <text> ESC [ 201 ~
+//
+// a paste is only complete once the end marker arrives, no matter how long that takes
+func decodePaste(buf []byte) (Keypress, int, decoded) {
+	end := bytes.Index(buf, pasteEnd)
+	if end < 0 {
+		return Keypress{}, 0, decodedIncomplete
+	}
+	text := string(buf[len(pasteStart):end])
+	n := end + len(pasteEnd)
+	if text == "" {
+		return Keypress{}, n, decodedUnknown
+	}
+	return Keypress{Paste: text}, n, decodedKey
+}
+
+// ESC [ ...
+func decodeCSI(buf []byte) (Keypress, int, decoded) {
+	if bytes.HasPrefix(buf, pasteStart) {
+		return decodePaste(buf)
+	}
+	// linux console function keys: ESC [ [ A-E
+	if len(buf) >= 3 && buf[2] == '[' {
+		if len(buf) < 4 {
+			return Keypress{}, 0, decodedIncomplete
+		}
+		if buf[3] >= 'A' && buf[3] <= 'E' {
+			return Keypress{Key: F1 - SpecialKey(buf[3]-'A')}, 4, decodedKey
+		}
+		return Keypress{}, 4, decodedUnknown
+	}
+	c, n, ok := parseCSI(buf)
+	if n == 0 {
+		return Keypress{}, 0, decodedIncomplete
+	}
+	if ok && c.marker == '<' && (c.final == 'M' || c.final == 'm') {
+		return decodeMouse(c, n)
+	}
+	// the kitty keyboard protocol flags and the primary device attributes
+	if ok && c.marker == '?' && (c.final == 'u' || c.final == 'c') {
+		return Keypress{reply: c.final}, n, decodedReply
+	}
+	if !ok || c.marker != 0 {
+		return Keypress{}, n, decodedUnknown
+	}
+	release := c.event() == 3
+	switch c.final {
+	case 'u':
+		return decodeKitty(c, n)
+	case '~':
+		if key, ok := tildeKeys[c.param(0, 0)]; ok {
+			return Keypress{Key: key, Mod: c.mod(), Release: release}, n, decodedKey
+		}
+	case 'Z':
+		return Keypress{Key: TAB, Mod: ModShift | c.mod(), Release: release}, n, decodedKey
+	default:
+		if key, ok := finalKeys[c.final]; ok {
+			return Keypress{Key: key, Mod: c.mod(), Release: release}, n, decodedKey
+		}
+	}
+	return Keypress{}, n, decodedUnknown
+}
+
+// key codes of the kitty keyboard protocol that are not the unicode character of the key
+var kittyKeys = map[int]Keypress{
+	9:   {Key: TAB},
+	13:  {Key: ENTER},
+	27:  {Key: ESC},
+	127: {Key: BACKSPACE_2},
+	// the keypad
+	57399: {Unicode: '0'},
+	57400: {Unicode: '1'},
+	57401: {Unicode: '2'},
+	57402: {Unicode: '3'},
+	57403: {Unicode: '4'},
+	57404: {Unicode: '5'},
+	57405: {Unicode: '6'},
+	57406: {Unicode: '7'},
+	57407: {Unicode: '8'},
+	57408: {Unicode: '9'},
+	57409: {Unicode: '.'},
+	57410: {Unicode: '/'},
+	57411: {Unicode: '*'},
+	57412: {Unicode: '-'},
+	57413: {Unicode: '+'},
+	57414: {Key: ENTER},
+	57415: {Unicode: '='},
+	57417: {Key: ARROW_LEFT},
+	57418: {Key: ARROW_RIGHT},
+	57419: {Key: ARROW_UP},
+	57420: {Key: ARROW_DOWN},
+	57421: {Key: PAGE_UP},
+	57422: {Key: PAGE_DOWN},
+	57423: {Key: HOME},
+	57424: {Key: END},
+	57425: {Key: INSERT},
+	57426: {Key: DELETE},
+}
+
+// the kitty keyboard protocol: ESC [ code[:alternates] ; mods[:event] u
+//
+// letters are always reported lower case, shift is in the modifiers
+func decodeKitty(c csi, n int) (Keypress, int, decoded) {
+	code := c.param(0, -1)
+	kp, ok := kittyKeys[code]
+	if !ok {
+		// the rest of the private use area is for keys we do not handle (caps lock, media keys, ...)
+		if code < 0x20 || (code >= 57344 && code <= 63743) || !utf8.ValidRune(rune(code)) {
+			return Keypress{}, n, decodedUnknown
+		}
+		kp = Keypress{Unicode: rune(code)}
+	}
+	kp.Mod = c.mod()
+	kp.Release = c.event() == 3
+	return kp, n, decodedKey
+}
+
+// SGR mouse reporting: ESC [ < button ; x ; y M (or m on release)
+func decodeMouse(c csi, n int) (Keypress, int, decoded) {
+	b, x, y := c.param(0, -1), c.param(1, -1), c.param(2, -1)
+	if b < 0 || x < 1 || y < 1 {
+		return Keypress{}, n, decodedUnknown
+	}
+	m := Mouse{X: x - 1, Y: y - 1}
+	if b&4 != 0 {
+		m.Mod |= ModShift
+	}
+	if b&8 != 0 {
+		m.Mod |= ModAlt
+	}
+	if b&16 != 0 {
+		m.Mod |= ModCtrl
+	}
+	switch {
+	case b&64 != 0:
+		m.Action = MouseScroll
+		m.Button = MouseButton(b&3) + WheelUp
+	case c.final == 'm':
+		m.Action = MouseRelease
+		m.Button = MouseButton(b & 3)
+	case b&32 != 0:
+		m.Action = MouseDrag
+		m.Button = MouseButton(b & 3)
+	default:
+		m.Action = MousePress
+		m.Button = MouseButton(b & 3)
+	}
+	return Keypress{Mouse: m}, n, decodedKey
+}
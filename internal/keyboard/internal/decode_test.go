@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		flush bool
+		want  Keypress
+		n     int
+		res   decoded
+	}{
+		{name: "letter", input: "a", want: Keypress{Unicode: 'a'}, n: 1},
+		{name: "multi byte rune", input: "é", want: Keypress{Unicode: 'é'}, n: 2},
+		{name: "partial rune", input: "\xc3", res: decodedIncomplete},
+		{name: "control", input: "\x01", want: Keypress{Key: CtrlA}, n: 1},
+		{name: "backspace", input: "\x7f", want: Keypress{Key: BACKSPACE_2}, n: 1},
+		{name: "lone escape waits", input: "\x1b", res: decodedIncomplete},
+		{name: "lone escape on timeout", input: "\x1b", flush: true, want: Keypress{Key: ESC}, n: 1},
+		{name: "partial csi on timeout", input: "\x1b[", flush: true, want: Keypress{Key: ESC}, n: 1},
+		{name: "arrow", input: "\x1b[A", want: Keypress{Key: ARROW_UP}, n: 3},
+		{name: "ctrl arrow", input: "\x1b[1;5C", want: Keypress{Key: ARROW_RIGHT, Mod: ModCtrl}, n: 6},
+		{name: "shift alt arrow", input: "\x1b[1;4D", want: Keypress{Key: ARROW_LEFT, Mod: ModShift | ModAlt}, n: 6},
+		{name: "ss3 arrow", input: "\x1bOB", want: Keypress{Key: ARROW_DOWN}, n: 3},
+		{name: "ss3 f1", input: "\x1bOP", want: Keypress{Key: F1}, n: 3},
+		{name: "csi f4 with modifier", input: "\x1b[1;2S", want: Keypress{Key: F4, Mod: ModShift}, n: 6},
+		{name: "f5", input: "\x1b[15~", want: Keypress{Key: F5}, n: 5},
+		{name: "f12", input: "\x1b[24~", want: Keypress{Key: F12}, n: 5},
+		{name: "ctrl delete", input: "\x1b[3;5~", want: Keypress{Key: DELETE, Mod: ModCtrl}, n: 6},
+		{name: "page down", input: "\x1b[6~", want: Keypress{Key: PAGE_DOWN}, n: 4},
+		{name: "linux console f2", input: "\x1b[[B", want: Keypress{Key: F2}, n: 4},
+		{name: "shift tab", input: "\x1b[Z", want: Keypress{Key: TAB, Mod: ModShift}, n: 3},
+		{name: "alt letter", input: "\x1bx", want: Keypress{Unicode: 'x', Mod: ModAlt}, n: 2},
+		{name: "alt ctrl letter", input: "\x1b\x01", want: Keypress{Key: CtrlA, Mod: ModAlt}, n: 2},
+		{name: "double escape", input: "\x1b\x1b", want: Keypress{Key: ESC}, n: 1},
+		{name: "incomplete csi", input: "\x1b[1;5", res: decodedIncomplete},
+		{name: "unknown csi", input: "\x1b[99~", n: 5, res: decodedUnknown},
+		{name: "only the first key", input: "\x1b[Aabc", want: Keypress{Key: ARROW_UP}, n: 3},
+		{name: "kitty escape", input: "\x1b[27u", want: Keypress{Key: ESC}, n: 5},
+		{name: "kitty ctrl i", input: "\x1b[105;5u", want: Keypress{Unicode: 'i', Mod: ModCtrl}, n: 8},
+		{name: "kitty ctrl bracket", input: "\x1b[91;5u", want: Keypress{Unicode: '[', Mod: ModCtrl}, n: 7},
+		{name: "kitty shift enter", input: "\x1b[13;2u", want: Keypress{Key: ENTER, Mod: ModShift}, n: 7},
+		{name: "kitty super hyper", input: "\x1b[97;25u", want: Keypress{Unicode: 'a', Mod: ModSuper | ModHyper}, n: 8},
+		{name: "kitty caps lock is dropped", input: "\x1b[97;69u", want: Keypress{Unicode: 'a', Mod: ModCtrl}, n: 8},
+		{name: "kitty release", input: "\x1b[97;1:3u", want: Keypress{Unicode: 'a', Release: true}, n: 9},
+		{name: "kitty repeat", input: "\x1b[97;1:2u", want: Keypress{Unicode: 'a'}, n: 9},
+		{name: "kitty arrow release", input: "\x1b[1;5:3A", want: Keypress{Key: ARROW_UP, Mod: ModCtrl, Release: true}, n: 8},
+		{name: "kitty keypad", input: "\x1b[57400u", want: Keypress{Unicode: '1'}, n: 8},
+		{name: "kitty modifier key", input: "\x1b[57441;2u", n: 10, res: decodedUnknown},
+		{name: "kitty flags reply", input: "\x1b[?1u", want: Keypress{reply: 'u'}, n: 5, res: decodedReply},
+		{name: "device attributes reply", input: "\x1b[?62;22c", want: Keypress{reply: 'c'}, n: 9, res: decodedReply},
+		{name: "osc reply", input: "\x1b]11;rgb:0000/2b2b/3636\x1b\\", want: Keypress{reply: ']', text: "11;rgb:0000/2b2b/3636"}, n: 25, res: decodedReply},
+		{name: "osc reply ended by bel", input: "\x1b]11;rgb:ff/ff/ff\ax", want: Keypress{reply: ']', text: "11;rgb:ff/ff/ff"}, n: 18, res: decodedReply},
+		{name: "incomplete osc", input: "\x1b]11;rgb:00\x1b", res: decodedIncomplete},
+		{name: "osc cut off", input: "\x1b]11;\x1b[A", n: 5, res: decodedUnknown},
+		{name: "alt bracket", input: "\x1b]", flush: true, want: Keypress{Unicode: ']', Mod: ModAlt}, n: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, res := decode([]byte(tt.input), tt.flush)
+			if res != tt.res {
+				t.Fatalf("result = %v, want %v", res, tt.res)
+			}
+			if res == decodedIncomplete {
+				return
+			}
+			if n != tt.n {
+				t.Errorf("n = %d, want %d", n, tt.n)
+			}
+			if (res == decodedKey || res == decodedReply) && got != tt.want {
+				t.Errorf("key = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyboard_DetectKitty(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "supported", input: "\x1b[?0u\x1b[?62;22c", want: true},
+		{name: "not supported", input: "\x1b[?62;22c", want: false},
+		{name: "no reply", input: "", want: false},
+		{name: "typeahead is kept", input: "x\x1b[?0uy\x1b[?62cz", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kb := NewKeyboard(slog.New(slog.NewTextHandler(io.Discard, nil)))
+			kb.in = iotest.OneByteReader(strings.NewReader(tt.input))
+			var out strings.Builder
+			got, err := kb.DetectKitty(&out, 50*time.Millisecond)
+			if err != nil && err != io.EOF {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("supported = %v, want %v", got, tt.want)
+			}
+			if out.String() != "\x1b[?u\x1b[c" {
+				t.Errorf("query = %q", out.String())
+			}
+			if tt.name != "typeahead is kept" {
+				return
+			}
+			for _, want := range "xyz" {
+				kp, err := kb.GetKeypress()
+				if err != nil || kp.Unicode != want {
+					t.Errorf("key = %+v, %v, want %q", kp, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyboard_QueryBackground(t *testing.T) {
+	kb := NewKeyboard(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	kb.in = iotest.OneByteReader(strings.NewReader("x\x1b]11;rgb:ffff/ffff/ffff\x1b\\y\x1b[?62cz"))
+	var out strings.Builder
+	got, err := kb.QueryBackground(&out, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "11;rgb:ffff/ffff/ffff" {
+		t.Errorf("reply = %q", got)
+	}
+	if out.String() != "\x1b]11;?\x1b\\\x1b[c" {
+		t.Errorf("query = %q", out.String())
+	}
+	for _, want := range "xyz" {
+		kp, err := kb.GetKeypress()
+		if err != nil || kp.Unicode != want {
+			t.Errorf("key = %+v, %v, want %q", kp, err, want)
+		}
+	}
+}
+
+func TestKeyboard_GetKeypress(t *testing.T) {
+	kb := NewKeyboard(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	// the sequences are split across reads, like a fast paste can be
+	kb.in = iotest.OneByteReader(strings.NewReader("a\x1b[1;5Aé\x1b"))
+	want := []Keypress{
+		{Unicode: 'a'},
+		{Key: ARROW_UP, Mod: ModCtrl},
+		{Unicode: 'é'},
+		{Key: ESC},
+	}
+	for _, w := range want {
+		got, err := kb.GetKeypress()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != w {
+			t.Errorf("key = %+v, want %+v", got, w)
+		}
+	}
+	if _, err := kb.GetKeypress(); err != io.EOF {
+		t.Errorf("error = %v, want EOF", err)
+	}
+}
+
+func TestDecodePaste(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		flush bool
+		want  Keypress
+		n     int
+		res   decoded
+	}{
+		{name: "paste", input: "\x1b[200~ab\r\x1b[Ac\x1b[201~x", want: Keypress{Paste: "ab\r\x1b[Ac"}, n: 19},
+		{name: "paste without end", input: "\x1b[200~abc", res: decodedIncomplete},
+		{name: "paste without end is not flushed", input: "\x1b[200~abc", flush: true, res: decodedIncomplete},
+		{name: "empty paste", input: "\x1b[200~\x1b[201~", n: 12, res: decodedUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, res := decode([]byte(tt.input), tt.flush)
+			if res != tt.res {
+				t.Fatalf("result = %v, want %v", res, tt.res)
+			}
+			if res == decodedIncomplete {
+				return
+			}
+			if n != tt.n {
+				t.Errorf("n = %d, want %d", n, tt.n)
+			}
+			if res == decodedKey && got != tt.want {
+				t.Errorf("key = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMouse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Mouse
+	}{
+		{name: "left press", input: "\x1b[<0;10;5M", want: Mouse{Action: MousePress, Button: MouseLeft, X: 9, Y: 4}},
+		{name: "left release", input: "\x1b[<0;10;5m", want: Mouse{Action: MouseRelease, Button: MouseLeft, X: 9, Y: 4}},
+		{name: "drag", input: "\x1b[<32;1;1M", want: Mouse{Action: MouseDrag, Button: MouseLeft, X: 0, Y: 0}},
+		{name: "wheel down", input: "\x1b[<65;3;4M", want: Mouse{Action: MouseScroll, Button: WheelDown, X: 2, Y: 3}},
+		{name: "ctrl right press", input: "\x1b[<18;200;100M", want: Mouse{Action: MousePress, Button: MouseRight, X: 199, Y: 99, Mod: ModCtrl}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, res := decode([]byte(tt.input), false)
+			if res != decodedKey || n != len(tt.input) {
+				t.Fatalf("result = %v, n = %d", res, n)
+			}
+			if got.Mouse != tt.want {
+				t.Errorf("mouse = %+v, want %+v", got.Mouse, tt.want)
+			}
+		})
+	}
+}
+
+type panicReader struct{}
+
+func (panicReader) Read([]byte) (int, error) { panic("boom") }
+
+func TestKeyboard_ReaderPanics(t *testing.T) {
+	kb := NewKeyboard(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	kb.in = panicReader{}
+	defer func() {
+		if p, ok := recover().(string); !ok || !strings.HasPrefix(p, "boom") {
+			t.Errorf("recovered %v, want the reader's panic", p)
+		}
+	}()
+	kb.GetKeypress()
+	t.Error("the reader's panic was not passed on")
+}
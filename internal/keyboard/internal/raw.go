@@ -6,9 +6,13 @@ package internal
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"unicode/utf8"
+	"runtime/debug"
+	"strconv"
+	"time"
 )
 
 type SpecialKey uint16
@@ -161,10 +165,62 @@ var specialKeys map[SpecialKey]string = map[SpecialKey]string{
 
 // represents a single keypress
 //
-// a key press can either be a Special Key or a unicode character
+// a key press can either be a Special Key or a unicode character, possibly with modifiers
+//
+// a bracketed paste is delivered as a single keypress with the pasted text,
+// and a mouse report as a single keypress with the mouse event
 type Keypress struct {
 	Key     SpecialKey
 	Unicode rune
+	Mod     Modifier
+	Paste   string
+	Mouse   Mouse
+	// the key was let go, only reported by the kitty keyboard protocol
+	Release bool
+	// the final byte of a reply from the terminal, 0 for input from the user
+	reply byte
+	// the text of an operating system command reply (']')
+	text string
+}
+
+type MouseAction int
+
+const (
+	MouseNone MouseAction = iota
+	MousePress
+	MouseRelease
+	MouseDrag
+	MouseScroll
+)
+
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	// no button, e.g. a release on terminals that do not say which button was released
+	MouseNoButton
+	WheelUp
+	WheelDown
+	WheelLeft
+	WheelRight
+)
+
+// a mouse event, X and Y are 0 indexed screen cells
+type Mouse struct {
+	Action MouseAction
+	Button MouseButton
+	X, Y   int
+	Mod    Modifier
+}
+
+func (kp *Keypress) IsMouse() bool {
+	return kp.Mouse.Action != MouseNone
+}
+
+func (kp *Keypress) IsPaste() bool {
+	return kp.Paste != ""
 }
 
 func (kp *Keypress) IsUnicode() bool {
@@ -173,6 +229,15 @@ func (kp *Keypress) IsUnicode() bool {
 
 // TODO: I'm not convinced that this method will work perfectly
 func (kp *Keypress) String() string {
+	if kp.reply != 0 {
+		return "REPLY"
+	}
+	if kp.IsPaste() {
+		return "PASTE"
+	}
+	if kp.IsMouse() {
+		return "MOUSE"
+	}
 	if kp.Unicode == 0 {
 		if name, ok := specialKeys[kp.Key]; ok {
 			return name
@@ -181,84 +246,217 @@ func (kp *Keypress) String() string {
 	return string(kp.Unicode)
 }
 
+// how long to wait for the rest of an escape sequence before deciding that ESC was pressed on its own
+const escTimeout = 25 * time.Millisecond
+
+type chunk struct {
+	b   []byte
+	err error
+	// the reader panicked, the panic goes on where the keys are read
+	panicked any
+}
+
 type Keyboard struct {
+	in io.Reader
+	// input read by the reader goroutine
+	chunks chan chunk
+	// bytes read, but not yet decoded
+	pending []byte
+	// the error that stopped the reader
+	err error
+	// keys typed while waiting for a reply from the terminal
+	queued []Keypress
 	logger *slog.Logger
 }
 
 func NewKeyboard(l *slog.Logger) *Keyboard {
 	return &Keyboard{
+		in:     os.Stdin,
 		logger: l.WithGroup("raw keyboard"),
 	}
 }
 
-// assumes that buf[0] = '\033'
-// TODO: this is stupid logic. it needs to be improved and the checks should be more explicit
-// they should not rely on the size of nread
-func parseEscape(buf []byte, nread int) Keypress {
-	if nread == 1 {
-		return Keypress{Key: ESC}
+// read from in instead of stdin
+//
+// must be called before the first key is read
+func (kb *Keyboard) SetInput(in io.Reader) {
+	kb.in = in
+}
+
+// read input in the background so that we can wait for the rest of a sequence with a timeout
+func (kb *Keyboard) read() {
+	// a panic cannot be recovered from on another goroutine, so it is handed to the one
+	// reading the keys, which can put the terminal back
+	defer func() {
+		if p := recover(); p != nil {
+			kb.chunks <- chunk{panicked: fmt.Sprintf("%v\n\nkeyboard reader: %s", p, debug.Stack())}
+		}
+	}()
+	for {
+		buf := make([]byte, 1024)
+		n, err := kb.in.Read(buf)
+		if n > 0 {
+			kb.chunks <- chunk{b: buf[:n]}
+		}
+		if err != nil {
+			kb.chunks <- chunk{err: err}
+			return
+		}
 	}
-	if nread == 3 {
-		switch buf[2] {
-		case 'A':
-			return Keypress{Key: ARROW_UP}
-		case 'B':
-			return Keypress{Key: ARROW_DOWN}
-		case 'C':
-			return Keypress{Key: ARROW_RIGHT}
-		case 'D':
-			return Keypress{Key: ARROW_LEFT}
-		case 'F':
-			return Keypress{Key: END}
-		case 'H':
-			return Keypress{Key: HOME}
+}
+
+// wait for more input
+//
+// returns false if nothing arrived in time
+func (kb *Keyboard) fill(timeout time.Duration) (bool, error) {
+	if kb.err != nil {
+		return false, kb.err
+	}
+	if kb.chunks == nil {
+		kb.chunks = make(chan chunk, 64)
+		go kb.read()
+	}
+	var c chunk
+	if timeout == 0 {
+		c = <-kb.chunks
+	} else {
+		select {
+		case c = <-kb.chunks:
+		case <-time.After(timeout):
+			return false, nil
 		}
 	}
-	if nread == 4 {
-		switch buf[2] {
-		case '1', '7':
-			return Keypress{Key: HOME}
-		case '3':
-			return Keypress{Key: DELETE}
-		case '4', '8':
-			return Keypress{Key: END}
-		case '5':
-			return Keypress{Key: PAGE_UP}
-		case '6':
-			return Keypress{Key: PAGE_DOWN}
+	if c.panicked != nil {
+		panic(c.panicked)
+	}
+	if c.err != nil {
+		kb.err = c.err
+		return false, c.err
+	}
+	kb.pending = append(kb.pending, c.b...)
+	return true, nil
+}
+
+var errTimeout = errors.New("timed out waiting for input")
+
+// the time left until the deadline, 0 (wait as long as it takes) if there is none
+func until(deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return 0
+	}
+	return max(time.Until(deadline), time.Nanosecond)
+}
+
+// decode the next keypress or reply from the terminal
+//
+// gives up with errTimeout if nothing arrives before the deadline
+func (kb *Keyboard) next(deadline time.Time) (Keypress, error) {
+	flush := false
+	for {
+		if len(kb.pending) == 0 {
+			got, err := kb.fill(until(deadline))
+			if err != nil {
+				return Keypress{}, err
+			}
+			if !got {
+				return Keypress{}, errTimeout
+			}
 		}
+		kp, n, res := decode(kb.pending, flush)
+		switch res {
+		case decodedIncomplete:
+			// on an error, decode what we have before reporting it
+			got, _ := kb.fill(escTimeout)
+			flush = !got
+			continue
+		case decodedUnknown:
+			kb.logger.Debug("unknown input", slog.String("sequence", strconv.Quote(string(kb.pending[:n]))))
+			kb.pending = kb.pending[n:]
+			flush = false
+			continue
+		}
+		kb.logger.Log(context.TODO(), slog.LevelDebug-1, "raw input", slog.String("sequence", strconv.Quote(string(kb.pending[:n]))), slog.String("key", kp.String()))
+		kb.pending = kb.pending[n:]
+		return kp, nil
 	}
-	return Keypress{Key: ESC}
 }
 
 func (kb *Keyboard) GetKeypress() (Keypress, error) {
-	var buf = make([]byte, 4)
-	var nread int
-	var err error
-	// TODO: instead of blocking for a for loop, maybe we can just use a channel
+	if len(kb.queued) > 0 {
+		kp := kb.queued[0]
+		kb.queued = kb.queued[1:]
+		return kp, nil
+	}
 	for {
-		nread, err = os.Stdin.Read(buf[:])
+		kp, err := kb.next(time.Time{})
 		if err != nil {
 			return Keypress{}, err
 		}
-		if nread > 0 {
-			break
+		if kp.reply != 0 {
+			kb.logger.Debug("dropping late reply from the terminal")
+			continue
 		}
+		return kp, nil
 	}
+}
 
-	var kp Keypress
-	switch {
-	case buf[0] == '\033':
-		kp = parseEscape(buf, nread)
-	case SpecialKey(buf[0]) <= Ctrl_UNDERSCORE || SpecialKey(buf[0]) == BACKSPACE_2:
-		kp = Keypress{Key: SpecialKey(buf[0])}
-	default:
-		r, _ := utf8.DecodeRune(buf[:nread])
-		if r == utf8.RuneError {
-			return Keypress{}, errors.New("invalid rune")
+// find out if the terminal supports the kitty keyboard protocol
+//
+// asks for the protocol flags, followed by the primary device attributes.
+// every terminal answers the second query, so when that reply comes without a reply to the first one, there is no support.
+// keys typed while waiting are kept for GetKeypress
+func (kb *Keyboard) DetectKitty(w io.Writer, timeout time.Duration) (bool, error) {
+	if _, err := io.WriteString(w, "\x1b[?u\x1b[c"); err != nil {
+		return false, err
+	}
+	deadline := time.Now().Add(timeout)
+	supported := false
+	for {
+		kp, err := kb.next(deadline)
+		if errors.Is(err, errTimeout) {
+			kb.logger.Debug("no reply to the device attributes query")
+			return supported, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch kp.reply {
+		case 'u':
+			supported = true
+		case 'c':
+			return supported, nil
+		default:
+			kb.queued = append(kb.queued, kp)
+		}
+	}
+}
+
+// ask the terminal for its background color (OSC 11)
+//
+// returns the text of the reply (e.g. 11;rgb:ffff/ffff/ffff), "" when there is none.
+// like DetectKitty, the device attributes query that follows tells when to stop waiting
+func (kb *Keyboard) QueryBackground(w io.Writer, timeout time.Duration) (string, error) {
+	if _, err := io.WriteString(w, "\x1b]11;?\x1b\\\x1b[c"); err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(timeout)
+	reply := ""
+	for {
+		kp, err := kb.next(deadline)
+		if errors.Is(err, errTimeout) {
+			kb.logger.Debug("no reply to the device attributes query")
+			return reply, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch kp.reply {
+		case ']':
+			reply = kp.text
+		case 'c':
+			return reply, nil
+		case 0:
+			kb.queued = append(kb.queued, kp)
 		}
-		kp = Keypress{Unicode: r}
 	}
-	kb.logger.Log(context.TODO(), slog.LevelDebug-1, "raw input", string(buf), slog.String("key", kp.String()))
-	return kp, nil
 }
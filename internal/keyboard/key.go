@@ -1,14 +1,26 @@
 package keyboard
 
-import "unicode"
+import (
+	"strings"
+	"unicode"
+)
 
 type Key rune
 
 func (k *Key) String() string {
-	if s, ok := specialKeys[*k]; ok {
-		return s
+	var sb strings.Builder
+	for _, m := range modifiers {
+		if k.Has(m.mod) {
+			sb.WriteString(m.name + "+")
+		}
+	}
+	base := k.Base()
+	if s, ok := specialKeys[base]; ok {
+		sb.WriteString(s)
+		return sb.String()
 	}
-	return string(*k)
+	sb.WriteRune(rune(base))
+	return sb.String()
 }
 
 func (k Key) IsDigit() bool {
@@ -16,6 +28,9 @@ func (k Key) IsDigit() bool {
 }
 
 func (k Key) IsUnicode() bool {
+	if k.Mods() != 0 {
+		return false
+	}
 	if k >= F1 && k <= Ctrl8 {
 		return false
 	}
@@ -24,6 +39,63 @@ func (k Key) IsUnicode() bool {
 	return basicPlane || supplementaryPlane
 }
 
+// modifiers are stored in the bits above the unicode range
+//
+// this way a modified key is still a single Key, and can be used in the bindings like any other
+const (
+	ModShift Key = 1 << (22 + iota)
+	ModAlt
+	ModCtrl
+	ModSuper
+	ModHyper
+	ModMeta
+
+	modMask = ModShift | ModAlt | ModCtrl | ModSuper | ModHyper | ModMeta
+)
+
+// in the order they are written in a key name (e.g. Shift+Alt+a)
+var modifiers = []struct {
+	mod  Key
+	name string
+}{
+	{ModShift, "Shift"},
+	{ModAlt, "Alt"},
+	{ModCtrl, "Ctrl"},
+	{ModSuper, "Super"},
+	{ModHyper, "Hyper"},
+	{ModMeta, "Meta"},
+}
+
+// the key without any modifiers
+func (k Key) Base() Key { return k &^ modMask }
+
+// the modifiers of the key
+func (k Key) Mods() Key { return k & modMask }
+
+// true if the key has all the modifiers in m
+func (k Key) Has(m Key) bool { return k&m == m }
+
+func (k Key) WithMods(m Key) Key { return k | (m & modMask) }
+
+// the key with Ctrl held on a character as its control key (e.g. 'a'|ModCtrl is CtrlA)
+//
+// an upper case letter is the control key with Shift, so the two cases stay different keys
+func (k Key) withCtrl() Key {
+	if !k.Has(ModCtrl) {
+		return k
+	}
+	r := rune(k.Base())
+	c, ok := ctrlKeys[unicode.ToLower(r)]
+	if !ok {
+		return k
+	}
+	m := k.Mods() &^ ModCtrl
+	if unicode.IsUpper(r) {
+		m |= ModShift
+	}
+	return c.WithMods(m)
+}
+
 // using the unicode private use area for special keys
 const (
 	F1 Key = 0xE000 + iota
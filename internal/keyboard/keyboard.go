@@ -4,17 +4,25 @@ package keyboard
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jcocozza/jte/internal/keyboard/internal"
 )
 
 var ErrInvalidKey error = errors.New("invalid key")
 
-
 type Keyboard struct {
-	raw    internal.Keyboard
-	logger *slog.Logger
+	raw internal.Keyboard
+	// whether the kitty keyboard protocol is turned on
+	kitty bool
+	// whether the terminal has been asked about the protocol, and what it said
+	detected, supported bool
+	logger              *slog.Logger
 }
 
 func NewKeyboard(l *slog.Logger) *Keyboard {
@@ -24,21 +32,200 @@ func NewKeyboard(l *slog.Logger) *Keyboard {
 	}
 }
 
+// read keys from in (e.g. /dev/tty) instead of stdin
+//
+// must be called before the first key is read
+func (k *Keyboard) SetInput(in io.Reader) {
+	k.raw.SetInput(in)
+}
+
+// an input event from the terminal
+//
+// most events are a single key press; a paste holds all of the pasted text instead,
+// and a mouse event holds the mouse report
+type Event struct {
+	Key   Key
+	Paste string
+	Mouse *Mouse
+	// the key was let go instead of pressed
+	Release bool
+}
+
+func (e Event) IsPaste() bool {
+	return e.Paste != ""
+}
+
 // this is the 'main' method used to extract user input
-func (k *Keyboard) GetKeypress() (Key, error) {
-	key, err := k.handleRawInput()
+func (k *Keyboard) GetEvent() (Event, error) {
+	ev, err := k.handleRawInput()
 	if err != nil {
-		return -1, err
+		return Event{Key: -1}, err
+	}
+	switch {
+	case ev.IsPaste():
+		k.logger.Debug("paste", slog.Int("length", len(ev.Paste)))
+	case ev.Mouse != nil:
+		k.logger.Debug("mouse", slog.Int("action", int(ev.Mouse.Action)), slog.Int("x", ev.Mouse.X), slog.Int("y", ev.Mouse.Y))
+	default:
+		k.logger.Debug("keypress", slog.String("key", ev.Key.String()))
+	}
+	return ev, nil
+}
+
+// the kitty keyboard protocol flags we ask for: disambiguate escape codes (1) and report event types (2)
+const kittyFlags = 1 | 2
+
+// how long to wait for the terminal to answer the protocol query
+const queryTimeout = 200 * time.Millisecond
+
+// turn on the kitty keyboard protocol if the terminal supports it
+//
+// the terminal has to be in raw mode already, w is the terminal's output.
+// without support, keys are read with the legacy parser.
+// the terminal is only asked the first time, after that (e.g. when resuming) the answer is reused
+func (k *Keyboard) EnableKittyProtocol(w io.Writer) (bool, error) {
+	if !k.detected {
+		ok, err := k.raw.DetectKitty(w, queryTimeout)
+		if err != nil {
+			return false, err
+		}
+		k.detected, k.supported = true, ok
+	}
+	if !k.supported {
+		return false, nil
 	}
-	k.logger.Debug("keypress", slog.String("key", key.String()))
-	return key, nil
+	if _, err := fmt.Fprintf(w, "\x1b[>%du", kittyFlags); err != nil {
+		return false, err
+	}
+	k.kitty = true
+	return true, nil
 }
 
-func (k *Keyboard) handleRawInput() (Key, error) {
-	kp, err := k.raw.GetKeypress()
+// put the terminal's keyboard protocol back the way it was
+func (k *Keyboard) DisableKittyProtocol(w io.Writer) error {
+	if !k.kitty {
+		return nil
+	}
+	k.kitty = false
+	_, err := io.WriteString(w, "\x1b[<u")
+	return err
+}
+
+// the terminal's background color, ok is false when it does not say
+//
+// the terminal has to be in raw mode already, w is the terminal's output
+func (k *Keyboard) QueryBackground(w io.Writer) (rgb [3]uint8, ok bool, err error) {
+	reply, err := k.raw.QueryBackground(w, queryTimeout)
 	if err != nil {
-		return -1, err
+		return rgb, false, err
+	}
+	rgb, ok = parseColorReply(reply)
+	return rgb, ok, nil
+}
+
+// 11;rgb:RRRR/GGGG/BBBB, where each part has 1 to 4 hex digits
+func parseColorReply(reply string) ([3]uint8, bool) {
+	var rgb [3]uint8
+	spec, ok := strings.CutPrefix(reply, "11;rgb:")
+	if !ok {
+		return rgb, false
+	}
+	parts := strings.Split(spec, "/")
+	if len(parts) != 3 {
+		return rgb, false
 	}
+	for i, p := range parts {
+		if len(p) < 1 || len(p) > 4 {
+			return rgb, false
+		}
+		v, err := strconv.ParseUint(p, 16, 16)
+		if err != nil {
+			return rgb, false
+		}
+		// scaled from 1 to 4 digits down to 8 bits
+		rgb[i] = uint8(v * 255 / (1<<(4*len(p)) - 1))
+	}
+	return rgb, true
+}
+
+// the keys that would have been sent if the text had been typed instead of pasted
+//
+// new lines (in any form) become ENTER
+func PasteKeys(text string) OrderedKeyList {
+	keys := OrderedKeyList{}
+	for _, r := range NormalizeNewlines(text) {
+		if r == '\n' {
+			keys.Append(ENTER)
+			continue
+		}
+		keys.Append(Key(r))
+	}
+	return keys
+}
+
+// terminals send \r for new lines in a paste, files may use \r\n
+func NormalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// the modifiers of a raw key press as key modifier bits
+func mods(kp internal.Keypress) Key {
+	var m Key
+	if kp.Mod&internal.ModShift != 0 {
+		m |= ModShift
+	}
+	if kp.Mod&internal.ModAlt != 0 {
+		m |= ModAlt
+	}
+	if kp.Mod&internal.ModCtrl != 0 {
+		m |= ModCtrl
+	}
+	if kp.Mod&internal.ModSuper != 0 {
+		m |= ModSuper
+	}
+	if kp.Mod&internal.ModHyper != 0 {
+		m |= ModHyper
+	}
+	if kp.Mod&internal.ModMeta != 0 {
+		m |= ModMeta
+	}
+	return m
+}
+
+func (k *Keyboard) handleRawInput() (Event, error) {
+	for {
+		kp, err := k.raw.GetKeypress()
+		if err != nil {
+			return Event{}, err
+		}
+		if kp.IsPaste() {
+			return Event{Paste: kp.Paste}, nil
+		}
+		if kp.IsMouse() {
+			return Event{Mouse: toMouse(kp)}, nil
+		}
+		key, err := toKey(kp)
+		if err == nil {
+			// the kitty protocol reports Ctrl+letter as the letter with a modifier
+			return Event{Key: key.WithMods(mods(kp)).withCtrl(), Release: kp.Release}, nil
+		}
+		k.logger.Debug("dropping key", slog.String("key", kp.String()))
+	}
+}
+
+// the control keys of the characters that are held with Ctrl
+//
+// unlike the legacy encoding, Ctrl+I, Ctrl+M and Ctrl+[ are not TAB, ENTER and ESC
+var ctrlKeys = map[rune]Key{
+	'a': CtrlA, 'b': CtrlB, 'c': CtrlC, 'd': CtrlD, 'e': CtrlE, 'f': CtrlF, 'g': CtrlG,
+	'h': CtrlH, 'i': CtrlI, 'j': CtrlJ, 'k': CtrlK, 'l': CtrlL, 'm': CtrlM, 'n': CtrlN,
+	'o': CtrlO, 'p': CtrlP, 'q': CtrlQ, 'r': CtrlR, 's': CtrlS, 't': CtrlT, 'u': CtrlU,
+	'v': CtrlV, 'w': CtrlW, 'x': CtrlX, 'y': CtrlY, 'z': CtrlZ,
+	'[': Ctrl_LSQBRACKET,
+}
+
+func toKey(kp internal.Keypress) (Key, error) {
 	// this should handle most cases...most is unicode represented
 	if kp.IsUnicode() {
 		return Key(kp.Unicode), nil
@@ -0,0 +1,55 @@
+package keyboard
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseColorReply(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  [3]uint8
+		ok    bool
+	}{
+		{"11;rgb:0000/2b2b/3636", [3]uint8{0x00, 0x2b, 0x36}, true},
+		{"11;rgb:ff/80/00", [3]uint8{0xff, 0x80, 0x00}, true},
+		{"11;rgb:f/0/8", [3]uint8{0xff, 0x00, 0x88}, true},
+		{"11;rgba:ff/ff/ff/ff", [3]uint8{}, false},
+		{"11;rgb:ff/ff", [3]uint8{}, false},
+		{"11;rgb:fffff/0/0", [3]uint8{}, false},
+		{"11;rgb:zz/0/0", [3]uint8{}, false},
+		{"", [3]uint8{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseColorReply(tt.reply)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("%q: got %v, %v, want %v, %v", tt.reply, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeyboard_CtrlUpperCase(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Key
+	}{
+		{"legacy", "\x01", CtrlA},
+		{"kitty", "\x1b[97;5u", CtrlA},
+		{"kitty with shift", "\x1b[97;6u", CtrlA | ModShift},
+		{"kitty upper case", "\x1b[65;5u", CtrlA | ModShift},
+	}
+	for _, tt := range tests {
+		k := NewKeyboard(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		k.SetInput(strings.NewReader(tt.in))
+		ev, err := k.GetEvent()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ev.Key != tt.want {
+			t.Errorf("%s: key = %s, want %s", tt.name, &ev.Key, &tt.want)
+		}
+	}
+}
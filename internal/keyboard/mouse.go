@@ -0,0 +1,55 @@
+package keyboard
+
+import "github.com/jcocozza/jte/internal/keyboard/internal"
+
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseDrag
+	MouseScroll
+)
+
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseNoButton
+	WheelUp
+	WheelDown
+	WheelLeft
+	WheelRight
+)
+
+// a mouse event
+//
+// X and Y are 0 indexed screen cells, Mods are the same modifier bits used for keys
+type Mouse struct {
+	Action MouseAction
+	Button MouseButton
+	X, Y   int
+	Mods   Key
+}
+
+func toMouse(kp internal.Keypress) *Mouse {
+	m := &Mouse{
+		Button: MouseButton(kp.Mouse.Button - internal.MouseLeft),
+		X:      kp.Mouse.X,
+		Y:      kp.Mouse.Y,
+		Mods:   mods(internal.Keypress{Mod: kp.Mouse.Mod}),
+	}
+	switch kp.Mouse.Action {
+	case internal.MousePress:
+		m.Action = MousePress
+	case internal.MouseRelease:
+		m.Action = MouseRelease
+	case internal.MouseDrag:
+		m.Action = MouseDrag
+	case internal.MouseScroll:
+		m.Action = MouseScroll
+	}
+	return m
+}
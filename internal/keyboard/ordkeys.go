@@ -1,5 +1,7 @@
 package keyboard
 
+import "strings"
+
 type OrderedKeyList []Key
 
 func (o OrderedKeyList) Collapse() string {
@@ -9,3 +11,82 @@ func (o OrderedKeyList) Collapse() string {
 func (o *OrderedKeyList) Append(k Key) {
 	*o = append(*o, k)
 }
+
+// the key list as editable text
+//
+// a character held with Ctrl is written as its control key (see withCtrl), special keys are written as their name in angle brackets (e.g. <ESC>), a literal '<' is written as <lt>
+func (o OrderedKeyList) Text() string {
+	var sb strings.Builder
+	for _, k := range o {
+		k = k.withCtrl()
+		if _, ok := specialKeys[k.Base()]; ok || k.Mods() != 0 {
+			sb.WriteString("<" + k.String() + ">")
+			continue
+		}
+		if k == '<' {
+			sb.WriteString("<lt>")
+			continue
+		}
+		sb.WriteRune(rune(k))
+	}
+	return sb.String()
+}
+
+var namedKeys = func() map[string]Key {
+	m := map[string]Key{"lt": '<'}
+	for k, name := range specialKeys {
+		m[name] = k
+	}
+	return m
+}()
+
+// look up a key by the name from Key.String
+func keyByName(name string) (Key, bool) {
+	if k, ok := namedKeys[name]; ok {
+		return k, true
+	}
+	for _, m := range modifiers {
+		if rest, ok := strings.CutPrefix(name, m.name+"+"); ok {
+			k, ok := keyByName(rest)
+			return k.WithMods(m.mod).withCtrl(), ok
+		}
+	}
+	if rs := []rune(name); len(rs) == 1 {
+		return Key(rs[0]), true
+	}
+	return 0, false
+}
+
+// the inverse of Text
+//
+// a new line is read as ENTER, anything in angle brackets that is not a key name is read literally
+func ParseKeys(text string) OrderedKeyList {
+	keys := OrderedKeyList{}
+	rs := []rune(text)
+	for i := 0; i < len(rs); i++ {
+		switch rs[i] {
+		case '\n':
+			keys.Append(ENTER)
+			continue
+		case '<':
+			if end := indexRune(rs[i+1:], '>'); end > 0 {
+				if k, ok := keyByName(string(rs[i+1 : i+1+end])); ok {
+					keys.Append(k)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		keys.Append(Key(rs[i]))
+	}
+	return keys
+}
+
+func indexRune(rs []rune, r rune) int {
+	for i, c := range rs {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,58 @@
+package keyboard
+
+import "testing"
+
+func TestOrderedKeyList_Text(t *testing.T) {
+	tests := []struct {
+		name string
+		keys OrderedKeyList
+		text string
+	}{
+		{name: "plain", keys: OrderedKeyList{'d', 'd', 'j'}, text: "ddj"},
+		{name: "special keys", keys: OrderedKeyList{'i', 'x', ESC, CtrlW}, text: "ix<ESC><Ctrl+W>"},
+		{name: "modified keys", keys: OrderedKeyList{ARROW_UP | ModShift, 'a' | ModAlt, CtrlA | ModShift}, text: "<Shift+ARROW_UP><Alt+a><Shift+Ctrl+A>"},
+		{name: "literal less than", keys: OrderedKeyList{'i', '<', 'a', '>', ESC}, text: "i<lt>a><ESC>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keys.Text(); got != tt.text {
+				t.Errorf("Text() = %q, want %q", got, tt.text)
+			}
+			got := ParseKeys(tt.text)
+			if got.Collapse() != tt.keys.Collapse() {
+				t.Errorf("ParseKeys(%q) = %v, want %v", tt.text, got, tt.keys)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		text string
+		want OrderedKeyList
+	}{
+		{text: "a\nb", want: OrderedKeyList{'a', ENTER, 'b'}},
+		{text: "<nope>", want: OrderedKeyList{'<', 'n', 'o', 'p', 'e', '>'}},
+		{text: "<", want: OrderedKeyList{'<'}},
+	}
+	for _, tt := range tests {
+		if got := ParseKeys(tt.text); got.Collapse() != tt.want.Collapse() {
+			t.Errorf("ParseKeys(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOrderedKeyList_CtrlUpperCase(t *testing.T) {
+	keys := OrderedKeyList{'A' | ModCtrl, 'a' | ModCtrl, CtrlA}
+	text := keys.Text()
+	if text != "<Shift+Ctrl+A><Ctrl+A><Ctrl+A>" {
+		t.Errorf("Text() = %q", text)
+	}
+	want := OrderedKeyList{CtrlA | ModShift, CtrlA, CtrlA}
+	if got := ParseKeys(text); got.Collapse() != want.Collapse() {
+		t.Errorf("ParseKeys(%q) = %v, want %v", text, got, want)
+	}
+	if got := ParseKeys("<Ctrl+w>"); got.Collapse() != (OrderedKeyList{CtrlW}).Collapse() {
+		t.Errorf("ParseKeys(<Ctrl+w>) = %v, want Ctrl+W", got)
+	}
+}
@@ -26,37 +26,37 @@ var specialKeys map[Key]string = map[Key]string{
 	Ctrl_TILDE:  "Ctrl+~",
 	//Ctrl2:     "Ctrl+2",
 	//CtrlSpace:     "Ctrl+SPACE",
-	CtrlA:     "Ctrl+A",
-	CtrlB:     "Ctrl+B",
-	CtrlC:     "Ctrl+C",
-	CtrlD:     "Ctrl+D",
-	CtrlE:     "Ctrl+E",
-	CtrlF:     "Ctrl+F",
-	CtrlG:     "Ctrl+G",
-	BACKSPACE: "BACKSPACE",
-	//CtrlH:           "Ctrl+H",
-	TAB: "TAB",
-	//CtrlI:           "Ctrl+I",
-	CtrlJ: "Ctrl+J",
-	CtrlK: "Ctrl+K",
-	CtrlL: "Ctrl+L",
-	ENTER: "ENTER",
-	//CtrlM:           "Ctrl+M",
-	CtrlN: "Ctrl+N",
-	CtrlO: "Ctrl+O",
-	CtrlP: "Ctrl+P",
-	CtrlQ: "Ctrl+Q",
-	CtrlR: "Ctrl+R",
-	CtrlS: "Ctrl+S",
-	CtrlT: "Ctrl+T",
-	CtrlU: "Ctrl+U",
-	CtrlV: "Ctrl+V",
-	CtrlW: "Ctrl+W",
-	CtrlX: "Ctrl+X",
-	CtrlY: "Ctrl+Y",
-	CtrlZ: "Ctrl+Z",
-	ESC:   "ESC",
-	//Ctrl_LSQBRACKET: "Ctrl+[",
+	CtrlA:           "Ctrl+A",
+	CtrlB:           "Ctrl+B",
+	CtrlC:           "Ctrl+C",
+	CtrlD:           "Ctrl+D",
+	CtrlE:           "Ctrl+E",
+	CtrlF:           "Ctrl+F",
+	CtrlG:           "Ctrl+G",
+	BACKSPACE:       "BACKSPACE",
+	CtrlH:           "Ctrl+H",
+	TAB:             "TAB",
+	CtrlI:           "Ctrl+I",
+	CtrlJ:           "Ctrl+J",
+	CtrlK:           "Ctrl+K",
+	CtrlL:           "Ctrl+L",
+	ENTER:           "ENTER",
+	CtrlM:           "Ctrl+M",
+	CtrlN:           "Ctrl+N",
+	CtrlO:           "Ctrl+O",
+	CtrlP:           "Ctrl+P",
+	CtrlQ:           "Ctrl+Q",
+	CtrlR:           "Ctrl+R",
+	CtrlS:           "Ctrl+S",
+	CtrlT:           "Ctrl+T",
+	CtrlU:           "Ctrl+U",
+	CtrlV:           "Ctrl+V",
+	CtrlW:           "Ctrl+W",
+	CtrlX:           "Ctrl+X",
+	CtrlY:           "Ctrl+Y",
+	CtrlZ:           "Ctrl+Z",
+	ESC:             "ESC",
+	Ctrl_LSQBRACKET: "Ctrl+[",
 	//Ctrl3:           "Ctrl+3",
 	Ctrl4: "Ctrl+4",
 	//Ctrl_BACKSLASH:  "Ctrl+\\",
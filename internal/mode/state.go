@@ -11,6 +11,8 @@ const (
 	Insert  Mode = "insert"
 	Normal  Mode = "normal"
 	Command Mode = "command"
+	Visual  Mode = "visual"
+	Replace Mode = "replace"
 )
 
 type StateMachine struct {
@@ -27,6 +29,8 @@ func NewStateMachine(l *slog.Logger) *StateMachine {
 			Insert:  {},
 			Normal:  {},
 			Command: {},
+			Visual:  {},
+			Replace: {},
 		},
 		logger: l.WithGroup("state-machine"),
 	}
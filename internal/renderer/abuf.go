@@ -1,6 +1,6 @@
 package renderer
 
-import "os"
+import "io"
 
 type abuf []byte
 
@@ -12,7 +12,7 @@ func (b *abuf) Clear() {
 	*b = []byte{}
 }
 
-func (b *abuf) Flush() {
-	os.Stdout.Write(*b)
+func (b *abuf) Flush(w io.Writer) {
+	w.Write(*b)
 	b.Clear()
 }
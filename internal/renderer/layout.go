@@ -3,14 +3,10 @@ package renderer
 import (
 	"log/slog"
 
+	"github.com/jcocozza/jte/internal/display"
 	"github.com/jcocozza/jte/internal/editor"
 )
 
-type LayoutRect struct {
-	X, Y       int
-	Rows, Cols int
-}
-
 type LayoutRenderer struct {
 	logger *slog.Logger
 }
@@ -21,39 +17,35 @@ func NewLayoutRenderer(l *slog.Logger) *LayoutRenderer {
 	}
 }
 
-func (r *LayoutRenderer) RenderLayout(e *editor.Editor, root *editor.SplitNode, pr PaneRenderer, screenrows int, screencols int) [][]byte {
-	screen := make([][]byte, screenrows)
-	for i := range screen {
-		screen[i] = make([]byte, screencols)
-		for j := range screen[i] {
-			screen[i][j] = ' '
-		}
-	}
-	r.RenderNode(e, root, pr, LayoutRect{X: 0, Y: 0, Rows: screenrows, Cols: screencols}, screen)
-	return screen
+// lay out the panes to fill the top screenrows of g and draw them, each pane is clipped to its rectangle
+func (r *LayoutRenderer) RenderLayout(e *editor.Editor, root *editor.SplitNode, pr PaneRenderer, g *display.Grid, screenrows int, screencols int) {
+	root.Resize(editor.Rect{X: 0, Y: 0, Rows: screenrows, Cols: screencols})
+	r.RenderNode(e, root, pr, g)
 }
 
-func (r *LayoutRenderer) RenderNode(e *editor.Editor, node *editor.SplitNode, pr PaneRenderer, rect LayoutRect, screen [][]byte) {
+func (r *LayoutRenderer) RenderNode(e *editor.Editor, node *editor.SplitNode, pr PaneRenderer, g *display.Grid) {
 	if node == nil {
 		return
 	}
+	rect := node.Rect
+	if rect.Rows < 1 || rect.Cols < 1 {
+		return
+	}
 	if node.Pane != nil {
-		psd := PaneStatusData{ Active: node.Pane.Active, Mode: e.Mode() }
-		rendered := pr.Render(rect.Rows, rect.Cols, psd, node.Pane.G, node.Pane.Buf)
-		for i := 0; i < len(rendered) && i+rect.Y < len(screen); i++ {
-			copy(screen[i+rect.Y][rect.X:], rendered[i])
+		psd := PaneStatusData{Active: node.Pane.Active, Mode: e.Mode(), Recording: e.Recording(), Zoomed: e.Zoomed()}
+		var sel *editor.Selection
+		if node.Pane.Active {
+			sel = e.Selection()
 		}
+		pr.Render(g.View(rect.X, rect.Y, rect.Rows, rect.Cols), psd, node.Pane, sel, e.Highlighting(node.Pane.Buf))
 		return
 	}
 	if node.Dir == editor.Vertical {
-		firstW := int(float64(rect.Cols) * node.FirstRatio)
-		secondW := rect.Cols - firstW
-		r.RenderNode(e, node.First, pr, LayoutRect{rect.X, rect.Y, rect.Rows, firstW}, screen)
-		r.RenderNode(e, node.Second, pr, LayoutRect{rect.X + firstW, rect.Y, rect.Rows, secondW}, screen)
-	} else {
-		firstH := int(float64(rect.Rows) * node.FirstRatio)
-		secondH := rect.Rows - firstH
-		r.RenderNode(e, node.First, pr, LayoutRect{rect.X, rect.Y, firstH, rect.Cols}, screen)
-		r.RenderNode(e, node.Second, pr, LayoutRect{rect.X, rect.Y + firstH, secondH, rect.Cols}, screen)
+		border := g.View(node.BorderCol(), rect.Y, rect.Rows, 1)
+		for y := 0; y < rect.Rows; y++ {
+			border.Set(0, y, display.Cell{Text: "|", Width: 1})
+		}
 	}
+	r.RenderNode(e, node.First, pr, g)
+	r.RenderNode(e, node.Second, pr, g)
 }
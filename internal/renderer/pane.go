@@ -1,107 +1,228 @@
 package renderer
 
 import (
-	"bytes"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 
+	"github.com/jcocozza/jte/internal/audit"
 	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/display"
+	"github.com/jcocozza/jte/internal/editor"
+	"github.com/jcocozza/jte/internal/grapheme"
 	"github.com/jcocozza/jte/internal/gutter"
+	"github.com/jcocozza/jte/internal/syntax"
+	"github.com/jcocozza/jte/internal/theme"
 )
 
-const TAB_STOP = 8
+const TAB_STOP = buffer.TAB_STOP
 
 type PaneStatusData struct {
 	Active bool
-	Mode string
+	Mode   string
+	// the register a macro is being recorded into, 0 if not recording
+	Recording rune
+	// the pane is zoomed to fill the screen
+	Zoomed bool
 }
 
 type PaneRenderer interface {
-	Render(rows int, cols int, psd PaneStatusData, g *gutter.Gutter, buf *buffer.Buffer) [][]byte
+	// draw the pane into v, the last row of v is the status line
+	//
+	// sel is the part of the buffer to highlight, it may be nil, and so may hl, the buffer's syntax highlighting
+	Render(v *display.View, psd PaneStatusData, p *editor.Pane, sel *editor.Selection, hl *editor.Highlighting)
 }
 
 type TextPaneRenderer struct {
-	rowoffset int
-	coloffset int
-
+	// the styles of the theme, they are set for every frame
+	styles theme.Styles
 	logger *slog.Logger
 }
 
 func NewTextPaneRenderer(l *slog.Logger) *TextPaneRenderer {
+	t, _ := theme.Get(theme.Default)
 	return &TextPaneRenderer{
+		styles: t.Styles(false),
 		logger: l.WithGroup("pane-renderer"),
 	}
 }
 
-func (r *TextPaneRenderer) scroll(panerows int, panecols int, buf *buffer.Buffer) {
-	if buf.Y() < r.rowoffset {
-		r.rowoffset = buf.Y()
+// draw the columns [coloffset, coloffset+cols) of the row into row vy of v
+//
+// y is the row's index in the buffer (for the selection), spans are its syntax highlighting
+func (r *TextPaneRenderer) renderRow(v *display.View, vy int, row buffer.BufRow, y int, coloffset int, spans []syntax.Span, sel *editor.Selection) {
+	_, cols := v.Size()
+	col := 0
+	end := coloffset + cols
+	// the first span that does not end before the glyph
+	next := 0
+	for _, g := range row.Glyphs() {
+		if g.Col >= end {
+			break
+		}
+		col = g.Col + g.Width
+		if g.Width == 0 || col <= coloffset {
+			continue
+		}
+		var style display.Style
+		for next < len(spans) && spans[next].End <= g.Index {
+			next++
+		}
+		if next < len(spans) && spans[next].Start <= g.Index {
+			style = r.styles.Syntax(spans[next].Type)
+		}
+		switch {
+		case g.Control:
+			style = r.styles[theme.Control]
+		case g.Audit != audit.None:
+			// characters that can hide what the text says (bidi controls, invisible characters, confusables)
+			style = r.styles[theme.Suspicious]
+		}
+		if sel != nil && sel.Contains(g.Index, y) {
+			style = r.styles[theme.Selection]
+		}
+		switch {
+		case g.Control || g.Audit.Hidden() || row[g.Index] == '\t':
+			// tabs and placeholders are a column per character, so they can be partly scrolled out of view
+			for i, c := range []rune(g.Text) {
+				if x := g.Col + i - coloffset; x >= 0 {
+					v.Set(x, vy, display.Cell{Text: string(c), Width: 1, Style: style})
+				}
+			}
+		case g.Col < coloffset:
+			// a character that is partly scrolled out of view is blanks
+			for c := coloffset; c < min(col, end); c++ {
+				v.Set(c-coloffset, vy, display.Cell{Text: " ", Width: 1, Style: style})
+			}
+		default:
+			// a wide character that does not fit at the right edge is clipped to a blank
+			v.Set(g.Col-coloffset, vy, display.Cell{Text: display.CellText([]rune(g.Text)), Width: g.Width, Style: style})
+		}
+	}
+	v.Fill(vy, max(col, coloffset)-coloffset, display.Style{})
+}
+
+// draw the gutter of buffer row y into row vy of v, v is as wide as the gutter
+//
+// the sign column comes first when it is shown, then the number. a wrapped row has them on its
+// first line only
+func (r *TextPaneRenderer) renderGutter(v *display.View, vy int, p *editor.Pane, y int, wrapped bool) {
+	_, cols := v.Size()
+	g := p.Gutter()
+	row := g.Row(y, p.Cursor().Y, &p.Buf.Signs)
+	if wrapped {
+		row = gutter.GutterRow{Num: -1}
 	}
-	if buf.Y() >= r.rowoffset+panerows {
-		r.rowoffset = buf.Y() - panerows + 1
+	x := 0
+	if cols > g.NumberWidth(len(p.Buf.Rows)) {
+		style := r.styles[theme.SignColumn]
+		if group, ok := editor.SignGroups[row.Sign.Source]; ok && row.Sign.Text != "" {
+			style = r.styles[group]
+		}
+		end := min(gutter.SignWidth, cols)
+		x = min(v.Print(0, vy, row.Sign.Text, style), end)
+		for ; x < end; x++ {
+			v.Set(x, vy, display.Cell{Text: " ", Width: 1, Style: r.styles[theme.SignColumn]})
+		}
 	}
-	if buf.X() < r.coloffset {
-		r.coloffset = buf.X()
+	style := r.styles[theme.LineNumber]
+	if row.Current {
+		style = r.styles[theme.CurrentLineNumber]
 	}
-	if buf.X() >= r.coloffset+panecols {
-		r.coloffset = buf.X() - panecols + 1
+	num := ""
+	if row.Num >= 0 {
+		num = strconv.Itoa(row.Num)
 	}
-}
-
-// hurestic
-func runeWidth(r rune) int {
-	if r < 128 {
-		return 1
+	// right aligned, with a space before the text, the cursor's own number in hybrid mode is left aligned
+	format := "%*s "
+	if row.Current && g.Mode() == gutter.Hybrid {
+		format = "%-*s "
 	}
-	return 2
+	v.Print(x, vy, fmt.Sprintf(format, cols-x-1, num), style)
 }
 
-func (r *TextPaneRenderer) renderRow(row buffer.BufRow) []byte {
-	var expanded []byte
-	col := 0
-	for _, b := range row {
-		if b == '\t' {
-			spaces := TAB_STOP - (col % TAB_STOP)
-			expanded = append(expanded, bytes.Repeat([]byte(" "), spaces)...)
-			col += spaces
-		} else {
-			expanded = append(expanded, []byte(string(b))...)
-			col += runeWidth(b)
-		}
+// draw segment s of a wrapped row into row vy of v, after its indent and showbreak
+func (r *TextPaneRenderer) renderSegment(v *display.View, vy int, row buffer.BufRow, y int, s buffer.Segment, showbreak string, spans []syntax.Span, sel *editor.Selection) {
+	rows, cols := v.Size()
+	v.Fill(vy, 0, display.Style{})
+	if s.Break {
+		v.Print(s.Indent, vy, showbreak, r.styles[theme.NonText])
 	}
-	return expanded
+	width := min(row.DisplayCol(s.End)-s.Col, cols-s.X)
+	r.renderRow(v.View(s.X, 0, rows, width), vy, row, y, s.Col, spans, sel)
 }
 
-func (r *TextPaneRenderer) renderStatus(cols int, psd PaneStatusData, buf *buffer.Buffer) []byte {
+// draw the status line into row y of v
+func (r *TextPaneRenderer) renderStatus(v *display.View, y int, psd PaneStatusData, p *editor.Pane) {
+	buf := p.Buf
+	_, cols := v.Size()
 	var displayModified string = ""
 	if buf.Modified {
 		displayModified = "(Δ)"
 	}
 	var displayRowNum int = 0
 	totalRows := len(buf.Rows)
-	currRow := buf.Y()
+	currRow := p.Cursor().Y
 	if totalRows != 0 {
 		displayRowNum = totalRows - 1 // -1 because i want a 0 indexed system
 	}
 	status := fmt.Sprintf("(%v) ln:%d/%d - %s %s", psd.Active, currRow, displayRowNum, displayModified, buf.Name)
-	spacer := bytes.Repeat([]byte(" "), cols-len(status)-len(psd.Mode))
-	statusBuf := append([]byte(psd.Mode), append(spacer, []byte(status)...)...)
-	return statusBuf
+	mode := psd.Mode
+	if psd.Recording != 0 {
+		mode += " recording @" + string(psd.Recording)
+	}
+	if psd.Zoomed {
+		mode += " zoom"
+	}
+	// the mode on the left and the status on the right, the status is cut off when they do not fit
+	gap := max(cols-grapheme.Width(mode)-grapheme.Width(status), 1)
+	style := r.styles[theme.StatusLine]
+	x := v.Print(0, y, mode+strings.Repeat(" ", gap)+status, style)
+	v.Fill(y, x, style)
 }
 
-func (r *TextPaneRenderer) Render(rows int, cols int, psd PaneStatusData, g *gutter.Gutter, buf *buffer.Buffer) [][]byte {
-	r.scroll(rows, cols, buf)
+func (r *TextPaneRenderer) Render(v *display.View, psd PaneStatusData, p *editor.Pane, sel *editor.Selection, hl *editor.Highlighting) {
+	buf := p.Buf
+	rows, cols := v.Size()
+	if rows < 1 || cols < 1 {
+		return
+	}
+	gw := p.GutterWidth(cols)
+	text := v.View(gw, 0, rows, cols-gw)
+	if psd.Active {
+		p.Scroll(rows-1, cols-gw)
+	}
 	r.logger.Debug("rendering buffer", slog.String("name", buf.Name))
-	paneBuf := make([][]byte, rows)
-	for i := 0; i < rows-1; i++ {
-		bufrownum := i + r.rowoffset
-		if bufrownum >= len(buf.Rows) {
-			paneBuf[bufrownum] = []byte("~")
-			continue
+	if hl != nil {
+		hl.Update(p.RowOffset + rows - 2)
+	}
+	// the screen line being drawn
+	i := 0
+	for y := p.RowOffset; y < len(buf.Rows) && i < rows-1; y++ {
+		var spans []syntax.Span
+		if hl != nil {
+			spans = hl.Spans(y)
 		}
-		paneBuf[i] = r.renderRow(buf.Rows[bufrownum])
+		segs := p.Segments(y, cols-gw)
+		first := 0
+		if y == p.RowOffset {
+			first = min(p.SegOffset, len(segs)-1)
+		}
+		for n := first; n < len(segs) && i < rows-1; n++ {
+			if gw > 0 {
+				r.renderGutter(v.View(0, 0, rows, gw), i, p, y, n > 0)
+			}
+			if p.Wrap {
+				r.renderSegment(text, i, buf.Rows[y], y, segs[n], p.WrapOptions.ShowBreak, spans, sel)
+			} else {
+				r.renderRow(text, i, buf.Rows[y], y, p.ColOffset, spans, sel)
+			}
+			i++
+		}
+	}
+	for ; i < rows-1; i++ {
+		v.Fill(i, v.Print(0, i, "~", r.styles[theme.NonText]), display.Style{})
 	}
-	// render status
-	paneBuf[rows-1] = r.renderStatus(cols, psd, buf)
-	return paneBuf
+	r.renderStatus(v, rows-1, psd, p)
 }
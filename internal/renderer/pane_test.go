@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/display"
+	"github.com/jcocozza/jte/internal/editor"
+	"github.com/jcocozza/jte/internal/fileutil"
+	"github.com/jcocozza/jte/internal/gutter"
+	"github.com/jcocozza/jte/internal/syntax"
+	"github.com/jcocozza/jte/internal/theme"
+)
+
+// the text of row y, wide characters are followed by "_" for the column they cover
+func gridRow(g *display.Grid, y int) string {
+	_, cols := g.Size()
+	var b strings.Builder
+	for x := range cols {
+		c := g.Cell(x, y)
+		if c.Width == 0 {
+			b.WriteString("_")
+			continue
+		}
+		b.WriteString(c.Text)
+	}
+	return b.String()
+}
+
+func TestTextPaneRenderer_RenderStatus(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewTextPaneRenderer(l)
+	buf := buffer.NewBuffer("name", "", false, []buffer.BufRow{{'a'}}, l)
+	buf.Modified = true
+	psd := PaneStatusData{Mode: "normal", Recording: 'q'}
+	for _, cols := range []int{1, 10, 30, 80} {
+		g := display.NewGrid(1, cols)
+		r.renderStatus(g.View(0, 0, 1, cols), 0, psd, &editor.Pane{Buf: buf})
+		got := gridRow(g, 0)
+		if !strings.HasPrefix(got, "n") {
+			t.Errorf("cols %d: status does not start with the mode: %q", cols, got)
+		}
+		if cols >= 60 && !strings.HasSuffix(got, "(Δ) name") {
+			t.Errorf("cols %d: status does not end with the name: %q", cols, got)
+		}
+	}
+}
+
+func TestTextPaneRenderer_RenderRow(t *testing.T) {
+	tests := []struct {
+		name      string
+		row       string
+		coloffset int
+		cols      int
+		want      string
+	}{
+		{name: "ascii", row: "abc", cols: 5, want: "abc  "},
+		{name: "wide", row: "a世b", cols: 5, want: "a世_b "},
+		{name: "combining", row: "e\u0301x", cols: 3, want: "e\u0301x "},
+		{name: "emoji sequence", row: "👍🏽!", cols: 4, want: "👍🏽_! "},
+		{name: "wide at the right edge", row: "ab世", cols: 3, want: "ab "},
+		{name: "wide partly scrolled out", row: "世ab", coloffset: 1, cols: 3, want: " ab"},
+		{name: "tab", row: "\tx", coloffset: 6, cols: 4, want: "  x "},
+		{name: "control", row: "a\rb\x1b", cols: 6, want: "a^Mb^["},
+		{name: "bidi control", row: "a\u202eb", cols: 10, want: "a<U+202E>b"},
+		{name: "c1 control partly scrolled out", row: "\u009bx", coloffset: 3, cols: 4, want: "9b>x"},
+	}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewTextPaneRenderer(l)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a column either side, to see that the row stays inside its view
+			g := display.NewGrid(1, tt.cols+2)
+			g.Set(0, 0, display.Cell{Text: "[", Width: 1})
+			g.Set(tt.cols+1, 0, display.Cell{Text: "]", Width: 1})
+			r.renderRow(g.View(1, 0, 1, tt.cols), 0, buffer.BufRow(tt.row), 0, tt.coloffset, nil, nil)
+			if got, want := gridRow(g, 0), "["+tt.want+"]"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTextPaneRenderer_RenderRow_Highlight(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewTextPaneRenderer(l)
+	g := display.NewGrid(1, 8)
+	row := buffer.BufRow("if 世x")
+	spans := []syntax.Span{{Start: 0, End: 2, Type: fileutil.KEYWORD}, {Start: 3, End: 4, Type: fileutil.STRING}}
+	r.renderRow(g.View(0, 0, 1, 8), 0, row, 0, 0, spans, nil)
+	keyword, str := r.styles.Syntax(fileutil.KEYWORD), r.styles.Syntax(fileutil.STRING)
+	want := []display.Style{keyword, keyword, {}, str, str, {}}
+	for x, style := range want {
+		if got := g.Cell(x, 0).Style; got != style {
+			t.Errorf("column %d: style %+v, want %+v", x, got, style)
+		}
+	}
+}
+
+func TestLayoutRenderer_ClipsPanes(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := editor.NewEditor(l)
+	left := buffer.NewBuffer("l", "", false, []buffer.BufRow{buffer.BufRow("世世世世世世")}, l)
+	right := buffer.NewBuffer("r", "", false, []buffer.BufRow{buffer.BufRow("xyz")}, l)
+	e.BM.Add(left)
+	e.BM.Add(right)
+	e.Root = editor.NewLayout(editor.Vertical, &editor.Pane{Buf: left}, &editor.Pane{Buf: right})
+	e.Focus(e.Root.First)
+
+	g := display.NewGrid(3, 12)
+	NewLayoutRenderer(l).RenderLayout(e, e.Root, NewTextPaneRenderer(l), g, 3, 12)
+	// the left pane is 5 columns and a border, the last wide character does not fit
+	if got, want := gridRow(g, 0), "世_世_ |xyz   "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTextPaneRenderer_Gutter(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rows := make([]buffer.BufRow, 12)
+	for i := range rows {
+		rows[i] = buffer.BufRow("abc")
+	}
+	buf := buffer.NewBuffer("b", "", false, rows, l)
+	buf.SetCursor(1, 1)
+	buf.Signs.Place(2, buffer.Sign{Source: "unicodeaudit", Text: "E"})
+	p := &editor.Pane{Buf: buf, G: &gutter.Gutter{Number: true, RelativeNumber: true}}
+
+	r := NewTextPaneRenderer(l)
+	g := display.NewGrid(4, 10)
+	r.Render(g.View(0, 0, 4, 10), PaneStatusData{Active: true}, p, nil, nil)
+	want := []string{"    1 abc ", "  2   abc ", "E   1 abc "}
+	for y, w := range want {
+		if got := gridRow(g, y); got != w {
+			t.Errorf("row %d = %q, want %q", y, got, w)
+		}
+	}
+	if got := g.Cell(0, 2).Style; got != r.styles[theme.Suspicious] {
+		t.Errorf("sign style %+v, want the style of the sign's source", got)
+	}
+	if got := g.Cell(4, 1).Style; got != r.styles[theme.CurrentLineNumber] {
+		t.Errorf("cursor line number style %+v", got)
+	}
+}
+
+func TestTextPaneRenderer_Wrap(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	buf := buffer.NewBuffer("b", "", false, []buffer.BufRow{buffer.BufRow("  one two three"), buffer.BufRow("x")}, l)
+	p := &editor.Pane{Buf: buf, G: &gutter.Gutter{Number: true}, Wrap: true}
+	p.WrapOptions = buffer.WrapOptions{LineBreak: true, BreakIndent: true, ShowBreak: "+"}
+
+	r := NewTextPaneRenderer(l)
+	g := display.NewGrid(5, 14)
+	r.Render(g.View(0, 0, 5, 14), PaneStatusData{Active: true}, p, nil, nil)
+	want := []string{"  1   one two ", "      +three  ", "  2 x         ", "~             "}
+	for y, w := range want {
+		if got := gridRow(g, y); got != w {
+			t.Errorf("row %d = %q, want %q", y, got, w)
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package renderer
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"syscall"
 
-	"github.com/jcocozza/jte/internal/buffer"
+	"github.com/jcocozza/jte/internal/display"
 	"github.com/jcocozza/jte/internal/editor"
+	"github.com/jcocozza/jte/internal/grapheme"
+	"github.com/jcocozza/jte/internal/keyboard"
+	"github.com/jcocozza/jte/internal/mode"
 	"github.com/jcocozza/jte/internal/term"
 )
 
@@ -19,26 +22,38 @@ type Renderer interface {
 }
 
 type TextRenderer struct {
-	rw *term.RawMode
+	// the terminal, everything is drawn here (stdout may be a pipe)
+	tty *os.File
+	rw  *term.RawMode
 
 	screenrows int
 	//initscreenrows int
 	screencols int
 
-	rowoffset int
-	coloffset int
+	// whether mouse reporting is turned on in the terminal
+	mouse bool
+	// the keyboard whose protocol has to be restored on exit
+	kb *keyboard.Keyboard
+	// the cursor shape that was last sent to the terminal
+	cursorShape string
 
 	lr *LayoutRenderer
 	pr *TextPaneRenderer
 
+	// what the terminal shows, so that a frame only sends what changed
+	screen display.Screen
+	// the terminal's background is light, the theme's light styles are used
+	light bool
+
 	// the content that is actually rendered to the screen
 	abuf abuf
 
 	logger *slog.Logger
 }
 
-func NewTextRenderer(l *slog.Logger) *TextRenderer {
+func NewTextRenderer(tty *os.File, l *slog.Logger) *TextRenderer {
 	return &TextRenderer{
+		tty:    tty,
 		abuf:   abuf{},
 		lr:     NewLayoutRenderer(l),
 		pr:     NewTextPaneRenderer(l),
@@ -47,17 +62,58 @@ func NewTextRenderer(l *slog.Logger) *TextRenderer {
 }
 
 func (r *TextRenderer) Setup() error {
-	rw, err := term.EnableRawMode()
+	rw, err := term.EnableRawMode(r.tty)
 	if err != nil {
 		return err
 	}
 	r.rw = rw
+	r.abuf.Append([]byte("\x1b[?1049h")) // switch to the alternate screen
+	r.abuf.Append([]byte("\x1b[?2004h")) // enable bracketed paste
+	r.abuf.Flush(r.tty)
 	return nil
 }
 
+// turn on the keyboard protocol the terminal supports best
+//
+// must be called after Setup, the terminal has to answer in raw mode
+func (r *TextRenderer) SetupKeyboard(kb *keyboard.Keyboard) {
+	ok, err := kb.EnableKittyProtocol(r.tty)
+	if err != nil {
+		r.logger.Error("failed to set up the keyboard protocol", "error", err)
+		return
+	}
+	r.logger.Info("keyboard protocol", slog.Bool("kitty", ok))
+	r.kb = kb
+}
+
+// find out what colors the terminal can show, and whether its background is light
+//
+// must be called after Setup, the terminal has to answer in raw mode
+func (r *TextRenderer) SetupColors(kb *keyboard.Keyboard) {
+	r.screen.Colors = display.DetectColorMode(os.Getenv)
+	bg, ok, err := kb.QueryBackground(r.tty)
+	if err != nil {
+		r.logger.Error("failed to query the background color", "error", err)
+		return
+	}
+	r.light = ok && display.IsLight(bg[0], bg[1], bg[2])
+	r.logger.Info("colors", slog.Int("mode", int(r.screen.Colors)), slog.Bool("background reply", ok), slog.Bool("light", r.light))
+}
+
+// put the terminal back the way we found it
 func (r *TextRenderer) cleanup() {
-	r.abuf.Append([]byte("\x1b[2J")) // clear entire screen
-	r.abuf.Flush()
+	// the keyboard protocol is kept per screen, so it goes before we leave the alternate screen
+	if r.kb != nil {
+		if err := r.kb.DisableKittyProtocol(r.tty); err != nil {
+			r.logger.Error("failed to restore the keyboard protocol", "error", err)
+		}
+	}
+	r.abuf.Append([]byte("\x1b[2J"))     // clear entire screen
+	r.abuf.Append([]byte("\x1b[?2004l")) // disable bracketed paste
+	r.setMouse(false)
+	r.setCursorShape(cursorDefault)
+	r.abuf.Append([]byte("\x1b[?1049l")) // back to the normal screen
+	r.abuf.Flush(r.tty)
 	if r.rw == nil {
 		return
 	}
@@ -67,6 +123,42 @@ func (r *TextRenderer) cleanup() {
 	}
 }
 
+// hand the terminal back to the shell and stop, until the shell continues us
+func (r *TextRenderer) Suspend() error {
+	r.cleanup()
+	// the whole process group, so that a pipeline we are part of stops with us
+	if err := syscall.Kill(0, syscall.SIGTSTP); err != nil {
+		r.logger.Error("failed to suspend", "error", err)
+	}
+	// continued, the shell has used the terminal in the meantime
+	if err := r.Setup(); err != nil {
+		return err
+	}
+	r.screen.Invalidate()
+	if r.kb != nil {
+		if _, err := r.kb.EnableKittyProtocol(r.tty); err != nil {
+			r.logger.Error("failed to set up the keyboard protocol", "error", err)
+		}
+	}
+	return nil
+}
+
+// put the terminal back, for a panic on a goroutine other than the one RecoverPanic is
+// deferred on
+func (r *TextRenderer) Restore() {
+	r.cleanup()
+}
+
+// put the terminal back if the editor panics, and let the panic go on
+//
+// must be deferred directly
+func (r *TextRenderer) RecoverPanic() {
+	if p := recover(); p != nil {
+		r.cleanup()
+		panic(p)
+	}
+}
+
 func (r *TextRenderer) ExitErr(err error) {
 	r.cleanup()
 	fmt.Fprintln(os.Stderr, err.Error())
@@ -82,40 +174,123 @@ func (r *TextRenderer) Exit(msg string) {
 	os.Exit(0)
 }
 
+// x and y are 1 indexed, and kept on the screen
 func (r *TextRenderer) drawCursor(x int, y int) {
+	x = min(max(x, 1), r.screenrows)
+	y = min(max(y, 1), r.screencols)
 	s := fmt.Sprintf("\x1b[%d;%dH", x, y) // set cursor position
 	r.abuf.Append([]byte(s))
 	r.abuf.Append([]byte("\x1b[?25h")) // show cursor
 }
 
-func (r *TextRenderer) drawCursorOnBuffer(offsetX int, offsetY int, buf *buffer.Buffer) {
-	y := (buf.Y() - r.rowoffset) + 1
-	actualCol := 0
-	for i := 0; i < buf.X(); i++ {
-		if buf.Rows[buf.Y()][i] == '\t' {
-			actualCol += TAB_STOP - (actualCol % TAB_STOP)
-		} else {
-			actualCol++
-		}
+// cursor shapes (DECSCUSR)
+const (
+	cursorDefault   = "\x1b[0 q"
+	cursorBlock     = "\x1b[2 q"
+	cursorUnderline = "\x1b[4 q"
+	cursorBar       = "\x1b[6 q"
+)
+
+// the cursor shape for each mode, a block if the mode is not here
+var modeCursorShapes = map[mode.Mode]string{
+	mode.Insert:  cursorBar,
+	mode.Command: cursorBar,
+	mode.Replace: cursorUnderline,
+}
+
+func (r *TextRenderer) setCursorShape(shape string) {
+	if shape == r.cursorShape {
+		return
 	}
-	r.drawCursor(offsetY+y, offsetX+actualCol+1)
+	r.cursorShape = shape
+	r.abuf.Append([]byte(shape))
+}
+
+// turn mouse reporting on or off
+//
+// reports presses, releases and drags in the SGR format
+func (r *TextRenderer) setMouse(on bool) {
+	if on == r.mouse {
+		return
+	}
+	r.mouse = on
+	if on {
+		r.abuf.Append([]byte("\x1b[?1000h\x1b[?1002h\x1b[?1006h"))
+		return
+	}
+	r.abuf.Append([]byte("\x1b[?1006l\x1b[?1002l\x1b[?1000l"))
+}
+
+func (r *TextRenderer) drawCursorOnBuffer(n *editor.SplitNode) {
+	x, y := n.Pane.CursorAt(n.TextCols())
+	r.drawCursor(n.Rect.Y+y+1, n.Rect.X+n.Pane.GutterWidth(n.Rect.Cols)+x+1)
+}
+
+// the bottom line of the screen, the command line or a message
+func (r *TextRenderer) renderCommandLine(e *editor.Editor, v *display.View) {
+	line, ok := e.CommandLine()
+	if !ok {
+		line = e.Message()
+	}
+	if line == "" {
+		line = e.CharacterInfo()
+	}
+	v.Fill(0, v.Print(0, 0, line, display.Style{}), display.Style{})
+}
+
+// read the size of the terminal
+//
+// returns false if the screen is too small to draw anything
+func (r *TextRenderer) updateSize() bool {
+	rows, cols, err := r.rw.WindowSize()
+	if err != nil {
+		// keep the last size we know about
+		r.logger.Error("failed to get the window size", "error", err)
+	} else if rows != r.screenrows || cols != r.screencols {
+		r.logger.Debug("screen size", slog.Int("rows", rows), slog.Int("cols", cols))
+		r.screenrows, r.screencols = rows, cols
+	}
+	return r.screenrows > 0 && r.screencols > 0
+}
+
+// repaint the whole screen in the next frame
+func (r *TextRenderer) Invalidate() {
+	r.screen.Invalidate()
+}
+
+// what drawing has cost so far
+func (r *TextRenderer) Stats() display.Stats {
+	return r.screen.Stats()
 }
 
 func (r *TextRenderer) Render(e *editor.Editor) {
 	r.logger.Debug("begin rendering")
+	if !r.updateSize() {
+		return
+	}
+	r.setMouse(e.Options.Mouse)
+	shape, ok := modeCursorShapes[mode.Mode(e.Mode())]
+	if !ok {
+		shape = cursorBlock
+	}
+	r.setCursorShape(shape)
 	r.abuf.Append([]byte("\x1b[?25l")) // hide cursor
-	r.abuf.Append([]byte("\x1b[2J"))   // clear entire screen
-	r.abuf.Append([]byte("\x1b[H"))    // cursor to home
 
-	rows, cols, _ := r.rw.WindowSize()
-	content := r.lr.RenderLayout(e, e.Root, r.pr, rows, cols)
-	for _, row := range content {
-		r.logger.Log(context.TODO(), slog.LevelDebug-1, "row", slog.String("row", string(row)))
-		r.abuf.Append(row)
-		//r.abuf.Append([]byte("\x1b[K"))
-	}
+	rows, cols := r.screenrows, r.screencols
+	grid := display.NewGrid(rows, cols)
+	r.pr.styles = e.Theme().Styles(r.light)
+	// the last row is for the command line
+	r.lr.RenderLayout(e, e.Root, r.pr, grid, rows-1, cols)
+	r.renderCommandLine(e, grid.View(0, rows-1, 1, cols))
+	r.abuf = r.screen.Draw(r.abuf, grid)
+	stats := r.screen.Stats()
+	r.logger.Debug("frame", slog.Int("bytes", stats.LastBytes), slog.Int("total", stats.TotalBytes), slog.Int("frames", stats.Frames))
 
-	r.drawCursorOnBuffer(0,0, e.Active.Pane.Buf)
-	r.abuf.Flush()
+	if line, ok := e.CommandLine(); ok {
+		r.drawCursor(rows, grapheme.Width(line)+1)
+	} else {
+		r.drawCursorOnBuffer(e.Active)
+	}
+	r.abuf.Flush(r.tty)
 	r.logger.Debug("end rendering")
 }
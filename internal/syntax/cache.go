@@ -0,0 +1,223 @@
+package syntax
+
+import (
+	"context"
+	"slices"
+)
+
+// a line as it was last highlighted
+type line struct {
+	spans []Span
+	// the states the line started and ended in
+	start, end State
+	// the line has not been edited since
+	valid bool
+}
+
+// the line is out of date when it was edited, or when the lines above now leave it in another state
+func (l *line) stale(st State) bool {
+	return !l.valid || l.start != st
+}
+
+func (l *line) highlight(hl Highlighter, text []rune, st State) {
+	spans, end := hl.Highlight(text, st)
+	*l = line{spans: spans, start: st, end: end, valid: true}
+}
+
+// the highlighting of a buffer's lines, kept as the buffer is edited
+//
+// after an edit only the lines from the edited one down are highlighted again, and only until a
+// line starts in the state it started in before: the lines below it cannot have changed
+type Cache struct {
+	hl Highlighter
+	// the lines that have been highlighted so far, from the top
+	lines []line
+	// the lines before dirty are up to date
+	dirty int
+	// the job that is highlighting for the cache, if any
+	job *Job
+}
+
+func NewCache(hl Highlighter) *Cache {
+	return &Cache{hl: hl}
+}
+
+func (c *Cache) Highlighter() Highlighter {
+	return c.hl
+}
+
+// start over with hl, e.g. when the file type changes
+func (c *Cache) Reset(hl Highlighter) {
+	if c.job != nil {
+		c.job.stop(0)
+	}
+	*c = Cache{hl: hl}
+}
+
+// tell the cache about an edit
+//
+// line y changed when added is 0, added lines were inserted at y when it is positive,
+// and -added lines were removed from y when it is negative
+//
+// a job that is running is stopped, the lines it highlights from y down are out of date
+func (c *Cache) Edit(y int, added int) {
+	y = max(y, 0)
+	if c.job != nil {
+		c.job.stop(y)
+	}
+	c.dirty = min(c.dirty, y)
+	if y > len(c.lines) {
+		// not highlighted yet
+		return
+	}
+	switch {
+	case added > 0:
+		c.lines = slices.Insert(c.lines, y, make([]line, added)...)
+	case added < 0:
+		// the line that moves up to y starts in a different state, if it matters
+		c.lines = slices.Delete(c.lines, y, min(y-added, len(c.lines)))
+	case y < len(c.lines):
+		c.lines[y].valid = false
+	}
+}
+
+// the spans of line y as it was last highlighted, they are out of date until the cache catches up
+func (c *Cache) Spans(y int) []Span {
+	if y < 0 || y >= len(c.lines) {
+		return nil
+	}
+	return c.lines[y].spans
+}
+
+// the state line y starts in
+func (c *Cache) startOf(y int) State {
+	if y == 0 {
+		return 0
+	}
+	return c.lines[y-1].end
+}
+
+// forget the lines past the last of n lines, e.g. after they were deleted
+func (c *Cache) trim(n int) {
+	if len(c.lines) > n {
+		c.lines = c.lines[:n]
+	}
+	c.dirty = min(c.dirty, len(c.lines))
+}
+
+// bring the lines through to up to date, of n lines where row(y) is the text of line y
+//
+// at most limit lines are highlighted (any number when it is negative), returns false when that
+// was not enough to reach to
+func (c *Cache) Update(n int, row func(y int) []rune, to int, limit int) bool {
+	c.trim(n)
+	for ; c.dirty <= min(to, n-1); c.dirty++ {
+		y := c.dirty
+		if y == len(c.lines) {
+			c.lines = append(c.lines, line{})
+		}
+		st := c.startOf(y)
+		if !c.lines[y].stale(st) {
+			continue
+		}
+		if limit == 0 {
+			return false
+		}
+		limit--
+		c.lines[y].highlight(c.hl, row(y), st)
+	}
+	return true
+}
+
+// highlighting done away from the cache, e.g. in the background
+//
+// it keeps the rows it highlights as they were when it started. rows are never changed in place
+// (see buffer.BufRow), so the buffer can change while it runs
+type Job struct {
+	hl Highlighter
+	// the first line of the job and the state it starts in
+	from  int
+	start State
+	rows  [][]rune
+	lines []line
+	// the lines the job has highlighted, from the top
+	done int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the first line edited since the job started, the lines from there down are out of date
+	edited int
+}
+
+// a job that brings the lines through to up to date, nil when they are
+//
+// the job gives up once ctx is done, or once the cache is edited
+func (c *Cache) Job(ctx context.Context, n int, row func(y int) []rune, to int) *Job {
+	c.trim(n)
+	to = min(to, n-1)
+	if c.dirty > to {
+		return nil
+	}
+	if c.job != nil {
+		c.job.stop(0)
+	}
+	j := &Job{hl: c.hl, from: c.dirty, start: c.startOf(c.dirty), edited: n}
+	j.ctx, j.cancel = context.WithCancel(ctx)
+	for y := c.dirty; y <= to; y++ {
+		j.rows = append(j.rows, row(y))
+		if y < len(c.lines) {
+			j.lines = append(j.lines, c.lines[y])
+		} else {
+			j.lines = append(j.lines, line{})
+		}
+	}
+	c.job = j
+	return j
+}
+
+// stop the job, what it highlights from line y down is thrown away
+func (j *Job) stop(y int) {
+	j.cancel()
+	j.edited = min(j.edited, y)
+}
+
+// highlight the job's lines, returns the error of its context when it gives up
+func (j *Job) Run() error {
+	st := j.start
+	for i := range j.lines {
+		if i%256 == 0 && j.ctx.Err() != nil {
+			return j.ctx.Err()
+		}
+		if j.lines[i].stale(st) {
+			j.lines[i].highlight(j.hl, j.rows[i], st)
+		}
+		st = j.lines[i].end
+		j.done = i + 1
+	}
+	return nil
+}
+
+// take the lines of a job once it has stopped, whether or not it got to the end
+//
+// the lines it highlighted above the first line edited since it started are kept, the rest
+// are thrown away. returns false when none were kept
+func (c *Cache) Finish(j *Job) bool {
+	j.cancel()
+	if c.job == j {
+		c.job = nil
+	}
+	end := min(j.from+j.done, j.edited)
+	// the lines above the job must be up to date, and the job's lines must follow on from them
+	if j.hl != c.hl || end <= j.from || c.dirty < j.from || j.from > len(c.lines) {
+		return false
+	}
+	for y := j.from; y < end; y++ {
+		if y < len(c.lines) {
+			c.lines[y] = j.lines[y-j.from]
+		} else {
+			c.lines = append(c.lines, j.lines[y-j.from])
+		}
+	}
+	c.dirty = max(c.dirty, end)
+	return true
+}
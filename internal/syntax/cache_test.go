@@ -0,0 +1,149 @@
+package syntax
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// counts the lines it highlights
+type countingHighlighter struct {
+	Highlighter
+	lines *int
+}
+
+func (h countingHighlighter) Highlight(line []rune, st State) ([]Span, State) {
+	*h.lines++
+	return h.Highlighter.Highlight(line, st)
+}
+
+type testRows [][]rune
+
+func (r testRows) row(y int) []rune { return r[y] }
+
+func rowsOf(lines ...string) testRows {
+	var rows testRows
+	for _, l := range lines {
+		rows = append(rows, []rune(l))
+	}
+	return rows
+}
+
+// the cache must agree with highlighting every line from the top
+func checkCache(t *testing.T, c *Cache, rows testRows) {
+	t.Helper()
+	var st State
+	for y, row := range rows {
+		var want []Span
+		want, st = c.hl.Highlight(row, st)
+		if got := c.Spans(y); !slices.Equal(got, want) {
+			t.Errorf("line %d: spans %v, want %v", y, got, want)
+		}
+	}
+}
+
+func TestCache_Edit(t *testing.T) {
+	n := 0
+	c := NewCache(countingHighlighter{For("go"), &n})
+	rows := rowsOf("x := 1", "/* a", "b */", "y := 2", "z := 3", "if")
+	if !c.Update(len(rows), rows.row, len(rows)-1, -1) || n != 6 {
+		t.Fatalf("highlighted %d lines, want 6", n)
+	}
+
+	// an edit that leaves the state alone highlights only its line
+	n = 0
+	rows[0] = []rune("x := 10")
+	c.Edit(0, 0)
+	c.Update(len(rows), rows.row, len(rows)-1, -1)
+	if n != 1 {
+		t.Errorf("a one line edit highlighted %d lines", n)
+	}
+	checkCache(t, c, rows)
+
+	// ending the comment early changes the line below, after it the state is the same again
+	n = 0
+	rows[1] = []rune("/* a */")
+	c.Edit(1, 0)
+	c.Update(len(rows), rows.row, len(rows)-1, -1)
+	if n != 2 {
+		t.Errorf("highlighted %d lines, want the edited line and b */", n)
+	}
+	checkCache(t, c, rows)
+
+	// inserting and removing lines
+	rows = slices.Insert(rows, 2, []rune("/* c"))
+	c.Edit(2, 1)
+	rows = slices.Delete(rows, 4, 5)
+	c.Edit(4, -1)
+	c.Update(len(rows), rows.row, len(rows)-1, -1)
+	checkCache(t, c, rows)
+}
+
+func TestCache_Limit(t *testing.T) {
+	rows := rowsOf("/* a", "b", "c", "d */")
+	c := NewCache(For("go"))
+	if c.Update(len(rows), rows.row, 3, 2) {
+		t.Fatal("4 lines were highlighted with a limit of 2")
+	}
+	if c.Spans(1) == nil || c.Spans(2) != nil {
+		t.Errorf("the first two lines should be done: %v %v", c.Spans(1), c.Spans(2))
+	}
+	if !c.Update(len(rows), rows.row, 3, 2) {
+		t.Fatal("the last two lines were not highlighted")
+	}
+	checkCache(t, c, rows)
+}
+
+func TestCache_Job(t *testing.T) {
+	rows := rowsOf("a", "`b", "c`", "d")
+	n := 0
+	c := NewCache(countingHighlighter{For("go"), &n})
+	j := c.Job(context.Background(), len(rows), rows.row, 3)
+	// the job keeps the rows it started with, an edit gives a row new runes
+	b := rows[1]
+	rows[1] = []rune("x")
+	if err := j.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Finish(j) {
+		t.Fatal("the job was not taken")
+	}
+	rows[1] = b
+	checkCache(t, c, rows)
+	if c.Job(context.Background(), len(rows), rows.row, 3) != nil {
+		t.Error("a job for lines that are up to date")
+	}
+
+	// an edit stops the job, it keeps the lines above the edit
+	rows[0] = []rune("/*")
+	c.Edit(0, 0)
+	j = c.Job(context.Background(), len(rows), rows.row, 3)
+	if err := j.Run(); err != nil {
+		t.Fatal(err)
+	}
+	rows[3] = []rune("*/")
+	c.Edit(3, 0)
+	if !c.Finish(j) {
+		t.Error("the lines above the edit were not taken")
+	}
+	n = 0
+	c.Update(len(rows), rows.row, 3, -1)
+	if n != 1 {
+		t.Errorf("%d lines highlighted after the job, want just the edited one", n)
+	}
+	checkCache(t, c, rows)
+
+	// an edit above the job leaves it nothing
+	c.Edit(3, 0)
+	j = c.Job(context.Background(), len(rows), rows.row, 3)
+	c.Edit(0, 0)
+	if err := j.Run(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want it cancelled", err)
+	}
+	if c.Finish(j) {
+		t.Error("a job below an edit was taken")
+	}
+	c.Update(len(rows), rows.row, 3, -1)
+	checkCache(t, c, rows)
+}
@@ -0,0 +1,268 @@
+package syntax
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+// a syntax definition file, e.g. go.json
+//
+//	{
+//	  "name": "go",
+//	  "files": ["*.go"],
+//	  "first_line": "^//go:build",
+//	  "keywords": [{"type": "keyword", "words": ["if", "else"]}],
+//	  "rules": [{"type": "comment", "pattern": "//.*"}],
+//	  "regions": [{"type": "comment", "start": "/\\*", "end": "\\*/", "multiline": true,
+//	               "contains": {"keywords": [{"type": "keyword", "words": ["TODO"]}]}}]
+//	}
+//
+// the types are the names in fileutil.SyntaxTypes, and patterns are go regular expressions
+type definition struct {
+	Name      string   `json:"name"`
+	Files     []string `json:"files"`
+	FirstLine string   `json:"first_line"`
+	contextDefinition
+}
+
+type contextDefinition struct {
+	// words are matched whole, they come before the rules
+	Keywords []struct {
+		Type  string   `json:"type"`
+		Words []string `json:"words"`
+	} `json:"keywords"`
+	Rules []struct {
+		Type    string `json:"type"`
+		Pattern string `json:"pattern"`
+	} `json:"rules"`
+	Regions []struct {
+		Type      string             `json:"type"`
+		Start     string             `json:"start"`
+		End       string             `json:"end"`
+		Escape    string             `json:"escape"`
+		Multiline bool               `json:"multiline"`
+		Contains  *contextDefinition `json:"contains"`
+	} `json:"regions"`
+}
+
+func syntaxType(name string) (fileutil.SyntaxType, error) {
+	i := slices.Index(fileutil.SyntaxTypes[:], name)
+	if i < 0 {
+		return fileutil.NORMAL, fmt.Errorf("unknown type: %q", name)
+	}
+	return fileutil.SyntaxType(i), nil
+}
+
+func (d *contextDefinition) compile() (*Context, error) {
+	ctx := &Context{}
+	for _, k := range d.Keywords {
+		typ, err := syntaxType(k.Type)
+		if err != nil {
+			return nil, err
+		}
+		if len(k.Words) == 0 {
+			continue
+		}
+		quoted := make([]string, len(k.Words))
+		for i, w := range k.Words {
+			quoted[i] = regexp.QuoteMeta(w)
+		}
+		re := regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+		ctx.Rules = append(ctx.Rules, Rule{Type: typ, Pattern: re})
+	}
+	for _, r := range d.Rules {
+		typ, err := syntaxType(r.Type)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Rules = append(ctx.Rules, Rule{Type: typ, Pattern: re})
+	}
+	for _, r := range d.Regions {
+		typ, err := syntaxType(r.Type)
+		if err != nil {
+			return nil, err
+		}
+		if r.Start == "" || r.End == "" {
+			return nil, fmt.Errorf("a region needs a start and an end")
+		}
+		start, err := regexp.Compile(r.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := regexp.Compile(r.End)
+		if err != nil {
+			return nil, err
+		}
+		region := &Region{Type: typ, Start: start, End: end, Escape: r.Escape, Multiline: r.Multiline}
+		if r.Contains != nil {
+			if region.Contains, err = r.Contains.compile(); err != nil {
+				return nil, err
+			}
+		}
+		ctx.Regions = append(ctx.Regions, region)
+	}
+	return ctx, nil
+}
+
+// read a language from its definition
+func Parse(data []byte) (*Language, error) {
+	var d definition
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if d.Name == "" {
+		return nil, fmt.Errorf("a syntax definition needs a name")
+	}
+	ctx, err := d.contextDefinition.compile()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	l := &Language{Name: d.Name, Files: d.Files, Context: *ctx}
+	for _, p := range l.Files {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("%s: bad file pattern %q: %w", d.Name, p, err)
+		}
+	}
+	if d.FirstLine != "" {
+		if l.FirstLine, err = regexp.Compile(d.FirstLine); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Name, err)
+		}
+	}
+	return l, nil
+}
+
+// the definitions that come with jte
+//
+//go:embed definitions/*.json
+var builtin embed.FS
+
+// the languages by name, a definition that is loaded later replaces one with the same name
+var languages = map[string]*Language{}
+
+// highlighters written in go, they are used instead of the built in definitions of their languages
+var highlighters = map[string]Highlighter{
+	"go": goHighlighter{},
+}
+
+// the languages whose definitions were loaded from a directory, they win over the highlighters
+var fromDirs = map[string]bool{}
+
+// the order the languages were loaded in, the last one to match a file wins
+var loadOrder []string
+
+func add(l *Language) {
+	if _, ok := languages[l.Name]; ok {
+		loadOrder = slices.DeleteFunc(loadOrder, func(n string) bool { return n == l.Name })
+	}
+	languages[l.Name] = l
+	loadOrder = append(loadOrder, l.Name)
+}
+
+func init() {
+	ls, errs := load(builtin, "definitions")
+	if len(errs) > 0 {
+		panic(errors.Join(errs...))
+	}
+	for _, l := range ls {
+		add(l)
+	}
+}
+
+// read the *.json definitions in dir of fsys
+//
+// a definition that cannot be read is skipped, its error is returned
+func load(fsys fs.FS, dir string) ([]*Language, []error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, []error{err}
+	}
+	var ls []*Language
+	var errs []error
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err == nil {
+			var l *Language
+			if l, err = Parse(data); err == nil {
+				ls = append(ls, l)
+				continue
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p, err))
+	}
+	return ls, errs
+}
+
+// where definitions are loaded from, in order: the system's, then the user's
+//
+// the user's are in $XDG_CONFIG_HOME/jte/syntax (~/.config/jte/syntax)
+func Dirs() []string {
+	dirs := []string{"/usr/share/jte/syntax", "/usr/local/share/jte/syntax"}
+	if config, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(config, "jte", "syntax"))
+	}
+	return dirs
+}
+
+// load the definitions in dirs, on top of the built in ones
+//
+// directories that do not exist are skipped
+func Load(dirs ...string) []error {
+	var errs []error
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		ls, dirErrs := load(os.DirFS(dir), ".")
+		for _, l := range ls {
+			add(l)
+			fromDirs[l.Name] = true
+		}
+		errs = append(errs, dirErrs...)
+	}
+	return errs
+}
+
+// the file type of a file from its name, or else from its first line
+func Detect(filePath string, firstLine string) fileutil.FileType {
+	base := filepath.Base(filePath)
+	for _, name := range slices.Backward(loadOrder) {
+		for _, p := range languages[name].Files {
+			if ok, _ := path.Match(p, base); ok && filePath != "" {
+				return fileutil.FileType(name)
+			}
+		}
+	}
+	for _, name := range slices.Backward(loadOrder) {
+		if re := languages[name].FirstLine; re != nil && re.MatchString(firstLine) {
+			return fileutil.FileType(name)
+		}
+	}
+	return fileutil.Unknown
+}
+
+// the highlighter for a file type, nil if there is none
+func For(ft fileutil.FileType) Highlighter {
+	l, ok := languages[string(ft)]
+	if h, builtin := highlighters[string(ft)]; builtin && !fromDirs[string(ft)] {
+		return h
+	}
+	if ok {
+		return l
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package syntax
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+// put the languages back the way they were after the test
+func restoreLanguages(t *testing.T) {
+	saved, order, dirs := maps.Clone(languages), slices.Clone(loadOrder), maps.Clone(fromDirs)
+	t.Cleanup(func() {
+		languages, loadOrder, fromDirs = saved, order, dirs
+	})
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+	}{
+		{"not json", `{`},
+		{"no name", `{"files": ["*.x"]}`},
+		{"unknown type", `{"name": "x", "rules": [{"type": "bold", "pattern": "a"}]}`},
+		{"bad pattern", `{"name": "x", "rules": [{"type": "string", "pattern": "("}]}`},
+		{"region without an end", `{"name": "x", "regions": [{"type": "string", "start": "a"}]}`},
+		{"bad nested pattern", `{"name": "x", "regions": [{"type": "string", "start": "a", "end": "b", "contains": {"rules": [{"type": "string", "pattern": "["}]}}]}`},
+		{"bad file pattern", `{"name": "x", "files": ["[a"]}`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.def)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	restoreLanguages(t)
+	system, user := t.TempDir(), t.TempDir()
+	write := func(dir, name, def string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(system, "ini.json", `{"name": "ini", "files": ["*.ini"], "rules": [{"type": "comment", "pattern": ";.*"}]}`)
+	write(system, "broken.json", `{"name": "broken", "rules": [{"type": "nope", "pattern": "a"}]}`)
+	// the user's go replaces the built in one
+	write(user, "go.json", `{"name": "go", "files": ["*.go"], "keywords": [{"type": "keyword", "words": ["jte"]}]}`)
+	write(user, "notes.txt", `not a definition`)
+
+	errs := Load(system, user, filepath.Join(user, "missing"))
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want one for broken.json", errs)
+	}
+	if got := Detect("a/b.ini", ""); got != "ini" {
+		t.Errorf("detected %q for an .ini file", got)
+	}
+	spans, _ := For("go").Highlight([]rune("jte if"), 0)
+	if len(spans) != 1 || spans[0] != (Span{Start: 0, End: 3, Type: fileutil.KEYWORD}) {
+		t.Errorf("the user's go definition is not used: %+v", spans)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path, firstLine string
+		want            fileutil.FileType
+	}{
+		{"main.go", "", "go"},
+		{"/src/x/types.pyi", "", "python"},
+		{"script", "#!/usr/bin/env python3", "python"},
+		{"", "#!/usr/bin/python", "python"},
+		{"README", "# a title", fileutil.Unknown},
+		{"", "", fileutil.Unknown},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.path, tt.firstLine); got != tt.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tt.path, tt.firstLine, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package syntax
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+// highlights go with the tokens of go/scanner, which gets runes, raw strings and numbers right
+// where regular expressions do not
+type goHighlighter struct{}
+
+// the states of go lines
+const (
+	goCode State = iota
+	// inside a /* comment
+	goComment
+	// inside a ` string
+	goRawString
+)
+
+var goBuiltins = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true, "delete": true,
+	"imag": true, "len": true, "make": true, "max": true, "min": true, "new": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true,
+}
+
+var goTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true,
+}
+
+var goConstants = map[string]bool{"true": true, "false": true, "nil": true, "iota": true}
+
+// a token of a line, at byte offset off
+type goToken struct {
+	off int
+	tok token.Token
+	lit string
+}
+
+func (t goToken) end() int {
+	if t.lit != "" {
+		return t.off + len(t.lit)
+	}
+	return t.off + len(t.tok.String())
+}
+
+func (goHighlighter) Highlight(line []rune, st State) ([]Span, State) {
+	s := string(line)
+	var spans []byteSpan
+	pos := 0
+	// the end of a comment or a raw string from the lines above
+	switch st {
+	case goComment, goRawString:
+		typ, end := fileutil.COMMENT, "*/"
+		if st == goRawString {
+			typ, end = fileutil.STRING, "`"
+		}
+		i := strings.Index(s, end)
+		if i < 0 {
+			return runeSpans(s, append(spans, byteSpan{0, len(s), typ})), st
+		}
+		pos = i + len(end)
+		spans = append(spans, byteSpan{0, pos, typ})
+	}
+	st = goCode
+
+	tokens := scanGo(s[pos:], pos)
+	// inside a func declaration, before its name
+	funcDecl, depth := false, 0
+	for i, t := range tokens {
+		var next token.Token
+		if i+1 < len(tokens) {
+			next = tokens[i+1].tok
+		}
+		typ := fileutil.NORMAL
+		switch {
+		case t.tok.IsKeyword():
+			typ = fileutil.KEYWORD
+		case t.tok == token.INT || t.tok == token.FLOAT || t.tok == token.IMAG:
+			typ = fileutil.NUMBER
+		case t.tok == token.STRING || t.tok == token.CHAR:
+			typ = fileutil.STRING
+			if strings.HasPrefix(t.lit, "`") && (len(t.lit) == 1 || !strings.HasSuffix(t.lit, "`")) {
+				st = goRawString
+			}
+		case t.tok == token.COMMENT:
+			typ = fileutil.COMMENT
+			if strings.HasPrefix(t.lit, "/*") && (len(t.lit) < 4 || !strings.HasSuffix(t.lit, "*/")) {
+				st = goComment
+			}
+		case t.tok == token.IDENT:
+			typ = goIdent(t.lit, next, funcDecl && depth == 0, i > 0 && tokens[i-1].tok == token.TYPE)
+		}
+		switch {
+		case t.tok == token.FUNC:
+			funcDecl, depth = true, 0
+		case !funcDecl:
+		case t.tok == token.LPAREN:
+			depth++
+		case t.tok == token.RPAREN:
+			depth--
+		case depth == 0:
+			// the name, or no name at all (a func literal or a func type)
+			funcDecl = false
+		}
+		if typ != fileutil.NORMAL {
+			spans = append(spans, byteSpan{t.off, min(t.end(), len(s)), typ})
+		}
+	}
+	return runeSpans(s, spans), st
+}
+
+// the type of an identifier followed by next
+//
+// inDecl is set for the place of a function's name in its declaration, afterType when it follows type
+func goIdent(name string, next token.Token, inDecl bool, afterType bool) fileutil.SyntaxType {
+	switch {
+	case goTypes[name] || afterType:
+		return fileutil.TYPE
+	case goConstants[name]:
+		return fileutil.IDENTIFIER
+	case goBuiltins[name] && next == token.LPAREN:
+		return fileutil.BUILTIN
+	case next == token.LPAREN || (inDecl && next == token.LBRACK):
+		return fileutil.FUNCTION
+	}
+	return fileutil.NORMAL
+}
+
+// the tokens of s, which starts at byte off of its line
+//
+// the semicolons the scanner puts at the ends of lines are left out
+func scanGo(s string, off int) []goToken {
+	src := []byte(s)
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	var sc scanner.Scanner
+	// an error (e.g. a string that does not end) still gives the token, which is all we need
+	sc.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+	var tokens []goToken
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			return tokens
+		}
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+		tokens = append(tokens, goToken{off: off + file.Offset(pos), tok: tok, lit: lit})
+	}
+}
@@ -0,0 +1,76 @@
+package syntax
+
+import "testing"
+
+func TestGoHighlighter(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "declaration and calls",
+			lines: []string{`func (r *T) Render(n int) error { return fmt.Errorf("%d", len(x)) }`},
+			want:  []string{`kw:func fn:Render type:int type:error kw:return fn:Errorf str:"%d" builtin:len |0`},
+		},
+		{
+			name:  "generic declaration",
+			lines: []string{`func Map[T any](xs []T) {}`},
+			want:  []string{`kw:func fn:Map type:any |0`},
+		},
+		{
+			name:  "a func literal has no name",
+			lines: []string{`f := func(x int) bool { return x == nil }`},
+			want:  []string{`kw:func type:int type:bool kw:return id:nil |0`},
+		},
+		{
+			name:  "type declaration",
+			lines: []string{`type Span struct{ Start int }`},
+			want:  []string{`kw:type type:Span kw:struct type:int |0`},
+		},
+		{
+			name:  "a builtin that is not called",
+			lines: []string{`len := 3`},
+			want:  []string{`num:3 |0`},
+		},
+		{
+			name:  "runes and numbers",
+			lines: []string{`'\'' + 0x_1F + 1e3 + 2i + 0o17 + '"'`},
+			want:  []string{`str:'\'' num:0x_1F num:1e3 num:2i num:0o17 str:'"' |0`},
+		},
+		{
+			name:  "comments in strings and strings in comments",
+			lines: []string{`"/* no" // "no"`},
+			want:  []string{`str:"/* no" com:// "no" |0`},
+		},
+		{
+			name:  "raw string over lines",
+			lines: []string{"s := `a \"", `// b`, "c` + x"},
+			want:  []string{"str:`a \" |2", `str:// b |2`, "str:c` |0"},
+		},
+		{
+			name:  "block comment over lines",
+			lines: []string{`x /* "a`, `b`, `*/ if`},
+			want:  []string{`com:/* "a |1`, `com:b |1`, `com:*/ kw:if |0`},
+		},
+		{
+			name:  "unicode",
+			lines: []string{`s := "世界" + é()`},
+			want:  []string{`str:"世界" fn:é |0`},
+		},
+	}
+	hl := For("go")
+	if _, ok := hl.(goHighlighter); !ok {
+		t.Fatalf("go files are highlighted with %T", hl)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightLines(hl, tt.lines...)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
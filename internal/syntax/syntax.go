@@ -0,0 +1,225 @@
+// syntax highlighting, a line at a time
+package syntax
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+// a part of a line to highlight, the runes [Start, End)
+type Span struct {
+	Start, End int
+	Type       fileutil.SyntaxType
+}
+
+// where a line ends, the next line starts there (e.g. inside a block comment)
+//
+// 0 is outside of any region
+type State int
+
+type Highlighter interface {
+	// the spans of line, in order and not overlapping, and the state at its end
+	Highlight(line []rune, st State) ([]Span, State)
+}
+
+// a pattern to highlight
+type Rule struct {
+	Type fileutil.SyntaxType
+	// when the pattern has a group, only the first group is highlighted (e.g. the name in `(\w+)\(`)
+	Pattern *regexp.Regexp
+}
+
+// the rules and regions that apply to some text, a language or the inside of a region
+//
+// where matches overlap, the one that starts first wins. of the ones that start at the same place,
+// regions win over rules, and otherwise the one that is listed first
+type Context struct {
+	Regions []*Region
+	Rules   []Rule
+}
+
+// the text from a start pattern to an end pattern, e.g. a string or a block comment
+type Region struct {
+	Type       fileutil.SyntaxType
+	Start, End *regexp.Regexp
+	// the character after the escape cannot end the region (e.g. \" in a string)
+	Escape string
+	// the region can go on over the next lines, otherwise it ends with its line.
+	// a region inside another one always ends with its line
+	Multiline bool
+	// what is highlighted inside the region (e.g. escapes in a string), it may be nil
+	Contains *Context
+}
+
+// the rules of a language
+type Language struct {
+	Name string
+	// patterns for the names of its files (e.g. *.go)
+	Files []string
+	// matches the first line of its files (e.g. a #! line), it may be nil
+	FirstLine *regexp.Regexp
+	Context
+}
+
+// a span in bytes, before it is turned into runes
+type byteSpan struct {
+	start, end int
+	typ        fileutil.SyntaxType
+}
+
+func (l *Language) Highlight(line []rune, st State) ([]Span, State) {
+	s := string(line)
+	var spans []byteSpan
+	pos := 0
+	if st > 0 && int(st) <= len(l.Regions) {
+		end, ended, _ := l.scan(s, 0, 0, l.Regions[st-1], &spans)
+		if !ended {
+			return runeSpans(s, spans), st
+		}
+		pos = end
+	}
+	_, _, open := l.scan(s, pos, pos, nil, &spans)
+	return runeSpans(s, spans), open
+}
+
+// highlight s from pos, inside region r (nil outside of any region)
+//
+// the text of r from last on has no span yet. returns the byte after the end of r, and false if
+// r goes on past the end of the line. outside of any region, open is the state at the end of the line
+func (l *Language) scan(s string, pos int, last int, r *Region, spans *[]byteSpan) (int, bool, State) {
+	ctx := &l.Context
+	if r != nil {
+		ctx = r.Contains
+	}
+	// give the text of r up to i the type of r
+	fill := func(i int) {
+		if r != nil && i > last {
+			*spans = append(*spans, byteSpan{last, i, r.Type})
+		}
+		last = max(last, i)
+	}
+	for {
+		endStart, endEnd, ended := len(s), len(s), r == nil
+		if r != nil {
+			endStart, endEnd, ended = r.end(s, pos)
+		}
+		start, end, typ, inner := -1, -1, fileutil.NORMAL, -1
+		if ctx != nil && pos < len(s) {
+			start, end, typ, inner = ctx.next(s, pos)
+		}
+		if start < 0 || start >= endStart {
+			fill(endEnd)
+			return endEnd, ended, 0
+		}
+		fill(start)
+		if inner < 0 {
+			if end == start {
+				// an empty match highlights nothing, look again after it
+				_, n := utf8.DecodeRuneInString(s[start:])
+				pos = start + n
+				continue
+			}
+			*spans = append(*spans, byteSpan{start, end, typ})
+			pos, last = end, end
+			continue
+		}
+		ir := ctx.Regions[inner]
+		e, innerEnded, _ := l.scan(s, end, start, ir, spans)
+		if !innerEnded {
+			// the inner region goes on past the line, and so does r
+			if r != nil {
+				return len(s), false, 0
+			}
+			if ir.Multiline {
+				return len(s), true, State(inner + 1)
+			}
+			return len(s), true, 0
+		}
+		pos, last = e, e
+	}
+}
+
+// the first match at or after pos
+//
+// region is the index of the region that starts there, or -1 for a rule. start is -1 when nothing matches
+func (c *Context) next(s string, pos int) (start, end int, typ fileutil.SyntaxType, region int) {
+	start, region = -1, -1
+	for i, r := range c.Regions {
+		loc := r.Start.FindStringIndex(s[pos:])
+		if loc != nil && (start < 0 || pos+loc[0] < start) {
+			start, end, typ, region = pos+loc[0], pos+loc[1], r.Type, i
+		}
+	}
+	for _, r := range c.Rules {
+		loc := r.Pattern.FindStringSubmatchIndex(s[pos:])
+		if loc == nil {
+			continue
+		}
+		if len(loc) >= 4 && loc[2] >= 0 {
+			loc = loc[2:4]
+		}
+		if start < 0 || pos+loc[0] < start {
+			start, end, typ, region = pos+loc[0], pos+loc[1], r.Type, -1
+		}
+	}
+	return start, end, typ, region
+}
+
+// the first end of the region at or after from, false when the region does not end on the line
+func (r *Region) end(s string, from int) (start int, end int, ok bool) {
+	for i := from; i <= len(s); {
+		loc := r.End.FindStringIndex(s[i:])
+		if loc == nil {
+			break
+		}
+		start := i + loc[0]
+		escaped := false
+		for j := i; r.Escape != ""; {
+			k := strings.Index(s[j:start], r.Escape)
+			if k < 0 {
+				break
+			}
+			j += k + len(r.Escape)
+			if j == start {
+				escaped = true
+				break
+			}
+			_, n := utf8.DecodeRuneInString(s[j:])
+			j += n
+		}
+		if !escaped {
+			return start, i + loc[1], true
+		}
+		if start == len(s) {
+			break
+		}
+		_, n := utf8.DecodeRuneInString(s[start:])
+		i = start + n
+	}
+	return len(s), len(s), false
+}
+
+// turn the byte offsets of spans into rune offsets
+func runeSpans(s string, spans []byteSpan) []Span {
+	if len(spans) == 0 {
+		return nil
+	}
+	out := make([]Span, len(spans))
+	b, r := 0, 0
+	// the rune index of byte offset i, the offsets only go up
+	index := func(i int) int {
+		for b < i {
+			_, n := utf8.DecodeRuneInString(s[b:])
+			b += n
+			r++
+		}
+		return r
+	}
+	for i, sp := range spans {
+		out[i] = Span{Start: index(sp.start), End: index(sp.end), Type: sp.typ}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+var typeNames = map[fileutil.SyntaxType]string{
+	fileutil.NUMBER:     "num",
+	fileutil.STRING:     "str",
+	fileutil.COMMENT:    "com",
+	fileutil.FUNCTION:   "fn",
+	fileutil.IDENTIFIER: "id",
+	fileutil.KEYWORD:    "kw",
+	fileutil.TYPE:       "type",
+	fileutil.BUILTIN:    "builtin",
+}
+
+// the spans of each line as "type:text", with the state at the end of the line
+func highlightLines(hl Highlighter, lines ...string) []string {
+	var out []string
+	var st State
+	for _, line := range lines {
+		var spans []Span
+		rs := []rune(line)
+		spans, st = hl.Highlight(rs, st)
+		var parts []string
+		for _, sp := range spans {
+			parts = append(parts, typeNames[sp.Type]+":"+string(rs[sp.Start:sp.End]))
+		}
+		out = append(out, fmt.Sprintf("%s |%d", strings.Join(parts, " "), st))
+	}
+	return out
+}
+
+func TestGoDefinition(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "keywords and calls",
+			lines: []string{`func main() { return fmt.Println(x) }`},
+			want:  []string{`kw:func fn:main kw:return fn:Println |0`},
+		},
+		{
+			name:  "strings are not greedy",
+			lines: []string{`a := "x" + "y"`},
+			want:  []string{`str:"x" str:"y" |0`},
+		},
+		{
+			name:  "escaped quotes",
+			lines: []string{`s := "a \"b\" \\" + 'c'`},
+			want:  []string{`str:"a \"b\" \\" str:'c' |0`},
+		},
+		{
+			name:  "no keywords or comments inside strings",
+			lines: []string{`x := "for // if"`},
+			want:  []string{`str:"for // if" |0`},
+		},
+		{
+			name:  "no strings inside comments",
+			lines: []string{`return // "not a string"`},
+			want:  []string{`kw:return com:// "not a string" |0`},
+		},
+		{
+			name:  "block comment over lines",
+			lines: []string{`x /* if`, `for "y" */ return 1`},
+			want:  []string{`com:/* if |1`, `com:for "y" */ kw:return num:1 |0`},
+		},
+		{
+			name:  "raw string over lines",
+			lines: []string{"s := `a", `// "b"`, "` + nil"},
+			want:  []string{"str:`a |3", `str:// "b" |3`, "str:` id:nil |0"},
+		},
+		{
+			name:  "an unterminated string ends with its line",
+			lines: []string{`x := "abc`, `y`},
+			want:  []string{`str:"abc |0`, ` |0`},
+		},
+		{
+			name:  "numbers",
+			lines: []string{`0x1F + 1_000 + 3.5e-2 + x2`},
+			want:  []string{`num:0x1F num:1_000 num:3.5e-2 |0`},
+		},
+		{
+			name:  "unicode before a span",
+			lines: []string{`"世界" // é`},
+			want:  []string{`str:"世界" com:// é |0`},
+		},
+	}
+	// the go definition, go files are highlighted with go/scanner
+	hl := languages["go"]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightLines(hl, tt.lines...)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPython(t *testing.T) {
+	got := highlightLines(For("python"), `def f(x): # c`, `    s = """a 'b'`, `c""" + None`)
+	want := []string{`kw:def fn:f com:# c |0`, `str:"""a 'b' |2`, `str:c""" id:None |0`}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNestedContext(t *testing.T) {
+	got := highlightLines(languages["go"], `x := 1 // TODO: "y"`, `/* a`, `FIXME */ z`)
+	want := []string{`num:1 com://  kw:TODO com:: "y" |0`, `com:/* a |1`, `kw:FIXME com: */ |0`}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFor_Unknown(t *testing.T) {
+	if For(fileutil.Unknown) != nil {
+		t.Error("a highlighter for an unknown file type")
+	}
+}
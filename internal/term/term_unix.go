@@ -16,14 +16,7 @@ const (
 const ioctlReadTermios = syscall.TIOCGETA
 const ioctlWriteTermios = syscall.TIOCSETA
 
-func enableRawMode() (*RawMode, error) {
-	// Open /dev/tty explicitly instead of using stdin
-	//fd, err := syscall.Open("/dev/tty", syscall.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	fd := syscall.Stdin
-
+func enableRawMode(fd int) (*RawMode, error) {
 	var termios syscall.Termios
 	_, _, errno := syscall.Syscall(
 		syscall.SYS_IOCTL,
@@ -32,7 +25,6 @@ func enableRawMode() (*RawMode, error) {
 		uintptr(unsafe.Pointer(&termios)),
 	)
 	if errno != 0 {
-		syscall.Close(fd)
 		return nil, errno
 	}
 
@@ -65,7 +57,6 @@ func enableRawMode() (*RawMode, error) {
 		uintptr(unsafe.Pointer(&termios)),
 	)
 	if errno != 0 {
-		syscall.Close(fd)
 		return nil, errno
 	}
 
@@ -89,8 +80,6 @@ func restore(r *RawMode) error {
 		uintptr(ioctlWriteTermios),
 		uintptr(unsafe.Pointer(&termios)),
 	)
-	// Always try to close the fd
-	syscall.Close(r.fd)
 	if errno != 0 {
 		return errno
 	}
@@ -99,7 +88,7 @@ func restore(r *RawMode) error {
 
 // todo: this is not guarenteed to work; see https://viewsourcecode.org/snaptoken/kilo/03.rawInputAndOutput.html#window-size-the-hard-way
 // returns row, col, err
-func getWindowSize() (int, int, error) {
+func getWindowSize(fd int) (int, int, error) {
 	var ws struct {
 		Row    uint16
 		Col    uint16
@@ -107,7 +96,7 @@ func getWindowSize() (int, int, error) {
 		Ypixel uint16
 	}
 	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(syscall.Stdout),
+		uintptr(fd),
 		uintptr(syscall.TIOCGWINSZ),
 		uintptr(unsafe.Pointer(&ws)))
 	if errno != 0 {
@@ -115,3 +104,14 @@ func getWindowSize() (int, int, error) {
 	}
 	return int(ws.Row), int(ws.Col), nil
 }
+
+func isTerminal(fd int) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(ioctlReadTermios),
+		uintptr(unsafe.Pointer(&termios)),
+	)
+	return errno == 0
+}
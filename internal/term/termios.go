@@ -1,22 +1,39 @@
 package term
 
+import "os"
+
 type Term interface {
-    WindowSize() (int, int, error)
+	WindowSize() (int, int, error)
+}
+
+// open the terminal the editor runs in
+//
+// this is not stdin or stdout, so that those can be pipes (e.g. git diff | jte -)
+func Open() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+// whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	return isTerminal(int(f.Fd()))
 }
 
 type RawMode struct {
-    originalState any
-    fd int
+	originalState any
+	fd            int
 }
 
-func EnableRawMode() (*RawMode, error) {
-    return enableRawMode()
+// put the terminal f in raw mode
+//
+// the caller owns f, Restore does not close it
+func EnableRawMode(f *os.File) (*RawMode, error) {
+	return enableRawMode(int(f.Fd()))
 }
 
 func (r *RawMode) Restore() error {
-    return restore(r)
+	return restore(r)
 }
 
 func (r *RawMode) WindowSize() (int, int, error) {
-    return getWindowSize()
+	return getWindowSize(r.fd)
 }
@@ -0,0 +1,284 @@
+// color themes: the styles of the highlight groups, from theme files
+package theme
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jcocozza/jte/internal/display"
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+// a kind of text that is drawn in a style of its own
+//
+// the syntax types are groups too, by their names in fileutil.SyntaxTypes
+type Group string
+
+const (
+	StatusLine Group = "statusline"
+	// the gutter
+	LineNumber Group = "linenumber"
+	// the number of the cursor's line
+	CurrentLineNumber Group = "linenumber.current"
+	SignColumn        Group = "signcolumn"
+	Selection         Group = "selection"
+	Search            Group = "search"
+	// the ~ of the rows past the end of the buffer
+	NonText Group = "nontext"
+	// control characters, drawn as ^X
+	Control Group = "control"
+	// hidden and confusable characters (see :unicodeaudit)
+	Suspicious Group = "suspicious"
+	// diagnostics
+	Error   Group = "error"
+	Warning Group = "warning"
+)
+
+// the group of a syntax type
+func SyntaxGroup(t fileutil.SyntaxType) Group {
+	return Group(fileutil.SyntaxTypes[t])
+}
+
+// every group a theme can style
+func Groups() []Group {
+	groups := []Group{StatusLine, LineNumber, CurrentLineNumber, SignColumn, Selection, Search, NonText, Control, Suspicious, Error, Warning}
+	for t := range fileutil.SyntaxTypes {
+		groups = append(groups, SyntaxGroup(fileutil.SyntaxType(t)))
+	}
+	return groups
+}
+
+// the style of each group, a group that is not there has the terminal's own style
+type Styles map[Group]display.Style
+
+// the style of text of syntax type t
+func (s Styles) Syntax(t fileutil.SyntaxType) display.Style {
+	return s[SyntaxGroup(t)]
+}
+
+type Theme struct {
+	Name string
+	// on a dark background, and on a light one
+	dark, light Styles
+}
+
+// the styles to draw with, light is set for a terminal with a light background
+func (t *Theme) Styles(light bool) Styles {
+	if light {
+		return t.light
+	}
+	return t.dark
+}
+
+// a theme file, e.g. default.json
+//
+//	{
+//	  "name": "default",
+//	  "styles": {
+//	    "keyword": {"fg": "red"},
+//	    "statusline": {"fg": "#eee8d5", "bg": "#073642", "attrs": ["bold"]}
+//	  },
+//	  "light": {
+//	    "statusline": {"fg": "#073642", "bg": "#eee8d5"}
+//	  }
+//	}
+//
+// the styles under light replace the others on a terminal with a light background.
+// colors are names (red, brightred, ...), palette numbers ("208") or #rrggbb, and are
+// changed to the closest ones the terminal can show
+type definition struct {
+	Name   string                     `json:"name"`
+	Styles map[string]styleDefinition `json:"styles"`
+	Light  map[string]styleDefinition `json:"light"`
+}
+
+type styleDefinition struct {
+	Fg    string   `json:"fg"`
+	Bg    string   `json:"bg"`
+	Attrs []string `json:"attrs"`
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+var attrNames = map[string]display.Attr{
+	"bold":          display.Bold,
+	"dim":           display.Dim,
+	"italic":        display.Italic,
+	"underline":     display.Underline,
+	"reverse":       display.Reverse,
+	"strikethrough": display.Strikethrough,
+}
+
+func parseColor(s string) (display.Color, error) {
+	if s == "" || s == "default" {
+		return display.DefaultColor, nil
+	}
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return 0, fmt.Errorf("bad color: %q", s)
+		}
+		return display.RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+	}
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return display.Indexed(uint8(n)), nil
+	}
+	name, bright := strings.CutPrefix(s, "bright")
+	i := slices.Index(colorNames, name)
+	if i < 0 {
+		return 0, fmt.Errorf("unknown color: %q", s)
+	}
+	if bright {
+		i += 8
+	}
+	return display.Indexed(uint8(i)), nil
+}
+
+func (d styleDefinition) compile() (display.Style, error) {
+	var s display.Style
+	var err error
+	if s.Fg, err = parseColor(d.Fg); err != nil {
+		return s, err
+	}
+	if s.Bg, err = parseColor(d.Bg); err != nil {
+		return s, err
+	}
+	for _, a := range d.Attrs {
+		attr, ok := attrNames[a]
+		if !ok {
+			return s, fmt.Errorf("unknown attribute: %q", a)
+		}
+		s.Attrs |= attr
+	}
+	return s, nil
+}
+
+func compileStyles(defs map[string]styleDefinition, into Styles) error {
+	groups := Groups()
+	for name, def := range defs {
+		if !slices.Contains(groups, Group(name)) {
+			return fmt.Errorf("unknown group: %q", name)
+		}
+		s, err := def.compile()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		into[Group(name)] = s
+	}
+	return nil
+}
+
+// read a theme from its file
+func Parse(data []byte) (*Theme, error) {
+	var d definition
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if d.Name == "" {
+		return nil, fmt.Errorf("a theme needs a name")
+	}
+	t := &Theme{Name: d.Name, dark: Styles{}, light: Styles{}}
+	if err := compileStyles(d.Styles, t.dark); err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	maps.Copy(t.light, t.dark)
+	if err := compileStyles(d.Light, t.light); err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	return t, nil
+}
+
+// the theme jte starts with
+const Default = "default"
+
+// the themes that come with jte
+//
+//go:embed themes/*.json
+var builtin embed.FS
+
+// the themes by name, a theme that is loaded later replaces one with the same name
+var themes = map[string]*Theme{}
+
+func init() {
+	ts, errs := load(builtin, "themes")
+	if len(errs) > 0 {
+		panic(errors.Join(errs...))
+	}
+	for _, t := range ts {
+		themes[t.Name] = t
+	}
+}
+
+// read the *.json themes in dir of fsys
+//
+// a theme that cannot be read is skipped, its error is returned
+func load(fsys fs.FS, dir string) ([]*Theme, []error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, []error{err}
+	}
+	var ts []*Theme
+	var errs []error
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err == nil {
+			var t *Theme
+			if t, err = Parse(data); err == nil {
+				ts = append(ts, t)
+				continue
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p, err))
+	}
+	return ts, errs
+}
+
+// where themes are loaded from, in order: the system's, then the user's
+//
+// the user's are in $XDG_CONFIG_HOME/jte/themes (~/.config/jte/themes)
+func Dirs() []string {
+	dirs := []string{"/usr/share/jte/themes", "/usr/local/share/jte/themes"}
+	if config, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(config, "jte", "themes"))
+	}
+	return dirs
+}
+
+// load the themes in dirs, on top of the built in ones
+//
+// directories that do not exist are skipped
+func Load(dirs ...string) []error {
+	var errs []error
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		ts, dirErrs := load(os.DirFS(dir), ".")
+		for _, t := range ts {
+			themes[t.Name] = t
+		}
+		errs = append(errs, dirErrs...)
+	}
+	return errs
+}
+
+// the theme called name
+func Get(name string) (*Theme, bool) {
+	t, ok := themes[name]
+	return t, ok
+}
+
+// the names of the themes, sorted
+func Names() []string {
+	return slices.Sorted(maps.Keys(themes))
+}
@@ -0,0 +1,107 @@
+package theme
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jcocozza/jte/internal/display"
+	"github.com/jcocozza/jte/internal/fileutil"
+)
+
+func TestParse(t *testing.T) {
+	th, err := Parse([]byte(`{
+		"name": "t",
+		"styles": {
+			"keyword": {"fg": "brightred", "attrs": ["bold", "italic"]},
+			"statusline": {"fg": "#102030", "bg": "236"},
+			"selection": {"attrs": ["reverse"]}
+		},
+		"light": {"statusline": {"fg": "black", "bg": "default"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark, light := th.Styles(false), th.Styles(true)
+	if got, want := dark.Syntax(fileutil.KEYWORD), (display.Style{Fg: display.Indexed(9), Attrs: display.Bold | display.Italic}); got != want {
+		t.Errorf("keyword = %+v, want %+v", got, want)
+	}
+	if got, want := dark[StatusLine], (display.Style{Fg: display.RGB(0x10, 0x20, 0x30), Bg: display.Indexed(236)}); got != want {
+		t.Errorf("status line = %+v, want %+v", got, want)
+	}
+	if got, want := light[StatusLine], (display.Style{Fg: display.Indexed(0)}); got != want {
+		t.Errorf("light status line = %+v, want %+v", got, want)
+	}
+	// the rest is the same on a light background
+	if light[Selection] != dark[Selection] || light.Syntax(fileutil.KEYWORD) != dark.Syntax(fileutil.KEYWORD) {
+		t.Error("the light styles do not fall back to the others")
+	}
+	if dark[Search] != (display.Style{}) {
+		t.Errorf("a group the theme leaves out has a style: %+v", dark[Search])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+	}{
+		{"not json", `{`},
+		{"no name", `{"styles": {}}`},
+		{"unknown group", `{"name": "x", "styles": {"cursorline": {}}}`},
+		{"unknown color", `{"name": "x", "styles": {"keyword": {"fg": "teal"}}}`},
+		{"short hex color", `{"name": "x", "styles": {"keyword": {"fg": "#fff"}}}`},
+		{"palette color out of range", `{"name": "x", "styles": {"keyword": {"fg": "256"}}}`},
+		{"unknown attribute", `{"name": "x", "styles": {"keyword": {"attrs": ["blink"]}}}`},
+		{"bad light style", `{"name": "x", "light": {"keyword": {"bg": "nope"}}}`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.def)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	def, ok := Get(Default)
+	if !ok {
+		t.Fatal("no default theme")
+	}
+	// the colors jte had before themes
+	if got := def.Styles(false).Syntax(fileutil.KEYWORD); got != (display.Style{Fg: display.Indexed(1)}) {
+		t.Errorf("default keyword = %+v", got)
+	}
+	if _, ok := Get("solarized"); !ok {
+		t.Error("no solarized theme")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	saved := maps.Clone(themes)
+	t.Cleanup(func() { themes = saved })
+	dir := t.TempDir()
+	write := func(name, def string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("mine.json", `{"name": "mine", "styles": {"comment": {"fg": "#808080"}}}`)
+	write("default.json", `{"name": "default", "styles": {"keyword": {"fg": "blue"}}}`)
+	write("broken.json", `{"name": "broken", "styles": {"nope": {}}}`)
+
+	errs := Load(dir, filepath.Join(dir, "missing"))
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want one for broken.json", errs)
+	}
+	if _, ok := Get("mine"); !ok {
+		t.Error("the user's theme is not loaded")
+	}
+	def, _ := Get(Default)
+	if got := def.Styles(false).Syntax(fileutil.KEYWORD); got.Fg != display.Indexed(4) {
+		t.Errorf("the user's default theme is not used: %+v", got)
+	}
+	if _, ok := Get("broken"); ok {
+		t.Error("a broken theme was loaded")
+	}
+}
@@ -1,42 +1,118 @@
 package main
 
 import (
-	"github.com/jcocozza/jte/internal/buffer"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jcocozza/jte/internal/cli"
 	"github.com/jcocozza/jte/internal/editor"
 	"github.com/jcocozza/jte/internal/logger"
 	"github.com/jcocozza/jte/internal/renderer"
+	"github.com/jcocozza/jte/internal/syntax"
+	"github.com/jcocozza/jte/internal/term"
+	"github.com/jcocozza/jte/internal/theme"
 )
 
+func fail(code int, format string, a ...any) {
+	fmt.Fprintf(os.Stderr, "jte: "+format+"\n", a...)
+	os.Exit(code)
+}
+
 func main() {
+	opts, err := cli.Parse(os.Args[1:])
+	if err != nil {
+		fail(cli.ExitUsage, "%s\ntry 'jte --help' for more information", err)
+	}
+	if opts.Help {
+		cli.PrintUsage(os.Stdout)
+		os.Exit(cli.ExitOK)
+	}
+	if opts.Version {
+		fmt.Println("jte", cli.Version)
+		os.Exit(cli.ExitOK)
+	}
+
 	f, err := logger.Init()
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
 
-	r := renderer.NewTextRenderer(logger.Logger)
+	if opts.Headless {
+		headless(opts)
+	}
+
+	// keys are read from the terminal itself, so that stdin can be a pipe
+	tty, err := term.Open()
+	if err != nil {
+		fail(cli.ExitError, "unable to open the terminal: %s", err)
+	}
+	defer tty.Close()
+
+	// the user's syntax definitions are loaded before the files are opened, so their file types are known,
+	// and their themes before the editor starts with the default one
+	loadErrs := append(syntax.Load(syntax.Dirs()...), theme.Load(theme.Dirs()...)...)
 	e := editor.NewEditor(logger.Logger)
+	e.Keyboard().SetInput(tty)
+	if !term.IsTerminal(os.Stdout) {
+		e.Pipe = os.Stdout
+	}
+	// stdin is read before the terminal is in raw mode
+	warnings, err := opts.Open(e, os.Stdin, logger.Logger)
+	if err != nil {
+		fail(cli.ExitError, "%s", err)
+	}
+	warnings = append(loadErrs, warnings...)
+	for _, c := range opts.Commands {
+		err := e.Execute(c)
+		if errors.Is(err, editor.ErrExit) {
+			os.Exit(cli.ExitOK)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("%s: %w", c, err))
+		}
+	}
 
+	r := renderer.NewTextRenderer(tty, logger.Logger)
 	err = r.Setup()
 	if err != nil {
 		panic(err)
 	}
+	defer r.RecoverPanic()
+	r.SetupKeyboard(e.Keyboard())
+	r.SetupColors(e.Keyboard())
 
-	buf := buffer.NewBuffer("[No Name]", "", false, []buffer.BufRow{{'f', 'o', 'o'}}, logger.Logger)
-	id := e.BM.Add(buf)
-	e.BM.SetCurrent(id)
+	// shown once the editor is running, the last one wins
+	for _, w := range warnings {
+		e.Post(func(e *editor.Editor) error { return w })
+	}
+	err = e.Run(r)
+	if errors.Is(err, editor.ErrExit) {
+		r.Exit("")
+	}
+	r.ExitErr(err)
+}
 
-	// this is some setup stuff we're going to have to figure out
-	p := &editor.Pane{nil, buf, true}
-	n := &editor.SplitNode{Pane: p}
-	e.Root = n
-	e.Active = n
-	r.Render(e) // initial render
-	for {
-		err := e.HandleKeypress()
-		if err != nil {
-			r.ExitErr(err)
-		}
-		r.Render(e)
+// run the commands without a terminal and exit
+func headless(opts *cli.Options) {
+	cmds, err := opts.HeadlessCommands()
+	if err != nil {
+		fail(cli.ExitError, "%s", err)
+	}
+	e := editor.NewEditor(logger.Logger)
+	if opts.Stdin() {
+		e.Pipe = os.Stdout
+	}
+	warnings, err := opts.Open(e, os.Stdin, logger.Logger)
+	if err != nil {
+		fail(cli.ExitError, "%s", err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "jte: %s\n", w)
+	}
+	if err := cli.RunHeadless(e, cmds); err != nil {
+		fail(cli.ExitError, "%s", err)
 	}
+	os.Exit(cli.ExitOK)
 }